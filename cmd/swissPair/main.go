@@ -2,11 +2,15 @@ package main
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	. "github.comPhantomvv1/SwissPairAPI/internal/auth"
 	. "github.comPhantomvv1/SwissPairAPI/internal/players"
+	. "github.comPhantomvv1/SwissPairAPI/internal/ratings"
 	. "github.comPhantomvv1/SwissPairAPI/internal/rounds"
+	. "github.comPhantomvv1/SwissPairAPI/internal/standings"
+	. "github.comPhantomvv1/SwissPairAPI/internal/teams"
 	. "github.comPhantomvv1/SwissPairAPI/internal/tournament"
 )
 
@@ -26,15 +30,65 @@ func main() {
 	t.PUT("/", UpdateTournament)
 	t.DELETE("/", DeleteTournament)
 	t.POST("/status", GetTournamentsWithStatus)
+	t.POST("/finish", FinishTournament)
+	t.POST("/open", OpenRegistration)
+	t.POST("/close", CloseRegistration)
+	t.POST("/start", StartTournament)
+	t.POST("/cancel", CancelTournament)
+	t.POST("/archive", ArchiveTournament)
 
 	p := r.Group("/player")
 	p.POST("/", CreatePlayer)
 	p.POST("/:tournamentID", GetPlayersForTournament)
 	p.DELETE("/", RemoveUserFromTournament)
+	p.POST("/withdraw", WithdrawPlayer)
+	p.POST("/register", RegisterForTournament)
+	p.DELETE("/register", LeaveTournament)
+	p.POST("/approve", ApproveRegistration)
+	p.GET("/registrations/:tournamentID", GetRegistrations)
+
+	te := r.Group("/team")
+	te.POST("/", CreateTeam)
+	te.GET("/:teamID", GetTeamInfo)
+	te.PUT("/roster", UpdateRoster)
+	te.POST("/enter", EnterTeam)
+	te.DELETE("/enter", RemoveTeamFromTournament)
+	te.GET("/tournament/:tournamentID", GetTeamsForTournament)
+	te.POST("/lineup", SetLineup)
 
 	ro := r.Group("/round")
 	ro.GET("/:tournamentID", GetAllRounds)
 	ro.POST("/", CreateRounds)
+	ro.DELETE("/", DeleteLastRound)
+	ro.PUT("/result", SetResult)
+	ro.POST("/report", ReportResult)
+	ro.POST("/bye", RequestBye)
+	ro.POST("/draft", ProposeRound)
+	ro.PUT("/pairing", EditPairing)
+	ro.POST("/publish", PublishRound)
+	ro.GET("/changes/:tournamentID", GetPairingChanges)
+	ro.GET("/schedule/:tournamentID", GetRoundSchedule)
+	ro.PUT("/schedule", SetRoundSchedule)
+	ro.DELETE("/schedule", RemoveRoundSchedule)
+
+	f := r.Group("/forbidden")
+	f.GET("/:tournamentID", GetTournamentForbiddenPairings)
+	f.POST("/", AddForbiddenPairing)
+	f.DELETE("/", RemoveForbiddenPairing)
+
+	r.GET("/standings/:tournamentID", GetTournamentStandings)
+	r.GET("/standings/teams/:tournamentID", GetTournamentTeamStandings)
+
+	b := r.Group("/bracket")
+	b.GET("/:tournamentID", GetTournamentBracket)
+	b.POST("/cut", MakeCut)
+
+	ra := r.Group("/rating")
+	ra.GET("/report/:tournamentID", GetRatingReport)
+	ra.GET("/history/:userID", GetRatingHistory)
+	ra.GET("/leaderboard", GetLeaderboard)
+
+	go RunScheduler(time.Minute)
 
 	r.Run(":42069")
 }
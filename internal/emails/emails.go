@@ -12,9 +12,9 @@ func RemoveEmail(userEmail, tournamentName string) error {
 	m.SetHeader("From", os.Getenv("SMTP_FROM"))
 	m.SetHeader("To", userEmail)
 	text := fmt.Sprintf("Hello, you have recieved this automated email to tell "+
-		"you that you have been removed from tournament %s. If you think that this has been a "+
-		"mistake please contact the tournament owner", tournamentName)
-	subject := fmt.Sprintf("Removing from tournament %s", tournamentName)
+		"you that the details of tournament %s have been updated. Please check its name and start date "+
+		"and contact the tournament owner if you have any questions", tournamentName)
+	subject := fmt.Sprintf("Changes to tournament %s", tournamentName)
 	m.SetHeader("Subject", subject)
 	m.SetBody("text/plain", text)
 
@@ -33,9 +33,9 @@ func NotifyChangeEmail(emails []string, tournamentName string) error {
 	m.SetHeader("To", os.Getenv("SMTP_FROM"))
 	m.SetHeader("Bcc", emails...)
 	text := fmt.Sprintf("Hello, you have recieved this automated email to tell "+
-		"you that you have been removed from tournament %s. If you think that this has been a "+
-		"mistake please contact the tournament owner", tournamentName)
-	subject := fmt.Sprintf("Removing from tournament %s", tournamentName)
+		"you that the details of tournament %s have been updated. Please check its name and start date "+
+		"and contact the tournament owner if you have any questions", tournamentName)
+	subject := fmt.Sprintf("Changes to tournament %s", tournamentName)
 	m.SetHeader("Subject", subject)
 	m.SetBody("text/plain", text)
 
@@ -47,3 +47,64 @@ func NotifyChangeEmail(emails []string, tournamentName string) error {
 
 	return nil
 }
+
+// sendEmail sends an automated plain text email to one user
+func sendEmail(to, subject, text string) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", os.Getenv("SMTP_FROM"))
+	m.SetHeader("To", to)
+	m.SetHeader("Subject", subject)
+	m.SetBody("text/plain", text)
+
+	d := gomail.NewDialer(os.Getenv("SMTP_FROM"), 465, os.Getenv("SMTP_EMAIL"), os.Getenv("SMTP_PASSWORD"))
+
+	return d.DialAndSend(m)
+}
+
+// RoundNotStartedEmail tells the owner of the tournament that a scheduled round couldn't be started and why
+func RoundNotStartedEmail(ownerEmail, tournamentName string, round int, reason string) error {
+	text := fmt.Sprintf("Hello, you have recieved this automated email to tell "+
+		"you that round %d of tournament %s couldn't be started at its scheduled time: %s. "+
+		"It will be started as soon as this is resolved", round, tournamentName, reason)
+	subject := fmt.Sprintf("Round %d of tournament %s hasn't started", round, tournamentName)
+
+	return sendEmail(ownerEmail, subject, text)
+}
+
+// RegisteredEmail confirms to the user that they play in the tournament
+func RegisteredEmail(userEmail, tournamentName string) error {
+	text := fmt.Sprintf("Hello, you have recieved this automated email to confirm "+
+		"that you are registered for tournament %s", tournamentName)
+	subject := fmt.Sprintf("Registration for tournament %s", tournamentName)
+
+	return sendEmail(userEmail, subject, text)
+}
+
+// WaitlistedEmail tells the user that the tournament is full and where they are on its waitlist
+func WaitlistedEmail(userEmail, tournamentName string, position int) error {
+	text := fmt.Sprintf("Hello, you have recieved this automated email to tell "+
+		"you that tournament %s is full and you are number %d on its waitlist. You will get "+
+		"another email when a place opens for you", tournamentName, position)
+	subject := fmt.Sprintf("Waitlist of tournament %s", tournamentName)
+
+	return sendEmail(userEmail, subject, text)
+}
+
+// ApprovalPendingEmail tells the user that the owner of the tournament has to approve their registration
+func ApprovalPendingEmail(userEmail, tournamentName string) error {
+	text := fmt.Sprintf("Hello, you have recieved this automated email to tell "+
+		"you that your registration for tournament %s is waiting for the approval of the tournament owner", tournamentName)
+	subject := fmt.Sprintf("Registration for tournament %s", tournamentName)
+
+	return sendEmail(userEmail, subject, text)
+}
+
+// RegistrationRejectedEmail tells the user that the owner of the tournament didn't approve their registration
+func RegistrationRejectedEmail(userEmail, tournamentName string) error {
+	text := fmt.Sprintf("Hello, you have recieved this automated email to tell "+
+		"you that your registration for tournament %s wasn't approved. If you think that this has been a "+
+		"mistake please contact the tournament owner", tournamentName)
+	subject := fmt.Sprintf("Registration for tournament %s", tournamentName)
+
+	return sendEmail(userEmail, subject, text)
+}
@@ -0,0 +1,245 @@
+package knockout
+
+import "errors"
+
+const (
+	BracketWinners = iota + 1
+	BracketLosers
+	BracketFinal
+)
+
+var (
+	ErrUnknownMatch = errors.New("Error there is no match with this id in the bracket")
+	ErrNotInMatch   = errors.New("Error the winner doesn't play in this match")
+)
+
+// slot is one of the two places of a match, filled either by a seeded player or by the winner or loser of an earlier match
+type slot struct {
+	player int64 // Seeded player, 0 for an empty seat
+	source int   // Id of the match that feeds the slot, 0 for a seeded slot
+	loser  bool  // The slot takes the loser of the source match instead of its winner
+}
+
+type Match struct {
+	ID      int    `json:"id"`
+	Bracket int    `json:"bracket"`
+	Round   int    `json:"round"` // Round inside its bracket
+	Player1 int64  `json:"player_1"`
+	Player2 int64  `json:"player_2"`
+	Winner  int64  `json:"winner"`
+	Loser   int64  `json:"loser"`
+	Decided bool   `json:"decided"`
+	Bye     bool   `json:"bye"`     // Decided without a game
+	Sources [2]int `json:"sources"` // Matches that feed the two places, 0 for seeded places
+
+	slots  [2]slot
+	known  [2]bool
+	result int64 // Reported winner
+	reset  bool  // Second grand final, only played when the player from the losers bracket wins the first one
+}
+
+type Bracket struct {
+	Double  bool    `json:"double"`
+	Matches []Match `json:"matches"`
+}
+
+// seedOrder returns the order of the seeds in the first round of a bracket of the given size,
+// so that the best seeds can only meet in the latest rounds
+func seedOrder(size int) []int {
+	order := []int{1}
+	for len(order) < size {
+		next := make([]int, 0, len(order)*2)
+		for _, seed := range order {
+			next = append(next, seed, len(order)*2+1-seed)
+		}
+		order = next
+	}
+
+	return order
+}
+
+func (b *Bracket) addMatch(bracket, round int, first, second slot) int {
+	id := len(b.Matches) + 1
+	match := Match{ID: id, Bracket: bracket, Round: round, slots: [2]slot{first, second}}
+	match.Sources = [2]int{first.source, second.source}
+	b.Matches = append(b.Matches, match)
+
+	return id
+}
+
+// pairSources adds a match for every two consecutive sources and returns the winners of these matches
+func (b *Bracket) pairSources(bracket, round int, sources []slot) []slot {
+	winners := make([]slot, 0, len(sources)/2)
+	for i := 0; i+1 < len(sources); i += 2 {
+		id := b.addMatch(bracket, round, sources[i], sources[i+1])
+		winners = append(winners, slot{source: id})
+	}
+
+	return winners
+}
+
+// NewBracket builds a single or double elimination bracket for the seeds, best seed first.
+// Fields that aren't a power of two get byes for the best seeds
+func NewBracket(seeds []int64, double bool) *Bracket {
+	b := &Bracket{Double: double}
+
+	size := 2
+	for size < len(seeds) {
+		size *= 2
+	}
+
+	round := make([]slot, 0, size)
+	for _, seed := range seedOrder(size) {
+		if seed <= len(seeds) {
+			round = append(round, slot{player: seeds[seed-1]})
+		} else {
+			round = append(round, slot{})
+		}
+	}
+
+	// Winners bracket, remembering the losers of every round for the losers bracket
+	var losers [][]slot
+	for number := 1; len(round) > 1; number++ {
+		start := len(b.Matches) + 1
+		round = b.pairSources(BracketWinners, number, round)
+
+		var roundLosers []slot
+		for id := start; id <= len(b.Matches); id++ {
+			roundLosers = append(roundLosers, slot{source: id, loser: true})
+		}
+		losers = append(losers, roundLosers)
+	}
+	champion := round[0]
+
+	if !double {
+		b.resolve()
+		return b
+	}
+
+	// Losers bracket, the losers of every round of the winners bracket drop in turn
+	number := 1
+	lower := losers[0]
+	if len(lower) > 1 {
+		lower = b.pairSources(BracketLosers, number, lower)
+		number++
+	}
+
+	for i := 1; i < len(losers); i++ {
+		drops := losers[i]
+		if i%2 != 0 {
+			// Reverse the order of the players who drop to avoid early rematches
+			reversed := make([]slot, len(drops))
+			for j, drop := range drops {
+				reversed[len(drops)-1-j] = drop
+			}
+			drops = reversed
+		}
+
+		var mixed []slot
+		for j := range lower {
+			mixed = append(mixed, lower[j], drops[j])
+		}
+		lower = b.pairSources(BracketLosers, number, mixed)
+		number++
+
+		if i < len(losers)-1 && len(lower) > 1 {
+			lower = b.pairSources(BracketLosers, number, lower)
+			number++
+		}
+	}
+
+	final := b.addMatch(BracketFinal, 1, champion, lower[0])
+	reset := b.addMatch(BracketFinal, 2, slot{source: final}, slot{source: final, loser: true})
+	b.Matches[reset-1].reset = true
+
+	b.resolve()
+	return b
+}
+
+// resolve fills the places of the matches from their sources and decides the matches that don't need a game
+func (b *Bracket) resolve() {
+	for i := range b.Matches {
+		match := &b.Matches[i]
+		players := [2]int64{}
+
+		for j, s := range match.slots {
+			if s.source == 0 {
+				players[j], match.known[j] = s.player, true
+				continue
+			}
+
+			source := b.Matches[s.source-1]
+			if !source.Decided {
+				match.known[j] = false
+				continue
+			}
+
+			if s.loser {
+				players[j] = source.Loser
+			} else {
+				players[j] = source.Winner
+			}
+			match.known[j] = true
+		}
+
+		match.Player1, match.Player2 = players[0], players[1]
+		match.Decided, match.Bye, match.Winner, match.Loser = false, false, 0, 0
+		if !match.known[0] || !match.known[1] {
+			continue
+		}
+
+		switch {
+		case match.reset && match.Player1 == b.Matches[match.slots[0].source-1].Player1:
+			// The champion of the winners bracket won the grand final, no second final is needed
+			match.Decided, match.Bye, match.Winner, match.Loser = true, true, match.Player1, match.Player2
+		case match.Player1 == 0 || match.Player2 == 0:
+			match.Decided, match.Bye = true, true
+			match.Winner = match.Player1 + match.Player2
+		case match.result != 0:
+			match.Decided, match.Winner = true, match.result
+			match.Loser = match.Player1 + match.Player2 - match.result
+		}
+	}
+}
+
+// SetWinner records the winner of a played match and advances the players through the bracket
+func (b *Bracket) SetWinner(matchID int, winner int64) error {
+	if matchID < 1 || matchID > len(b.Matches) {
+		return ErrUnknownMatch
+	}
+
+	match := &b.Matches[matchID-1]
+	if winner != match.Player1 && winner != match.Player2 {
+		return ErrNotInMatch
+	}
+
+	match.result = winner
+	b.resolve()
+	return nil
+}
+
+// Ready returns the matches that have both players and still need to be played
+func (b *Bracket) Ready() []Match {
+	ready := make([]Match, 0)
+	for _, match := range b.Matches {
+		if match.known[0] && match.known[1] && !match.Decided {
+			ready = append(ready, match)
+		}
+	}
+
+	return ready
+}
+
+// Champion returns the winner of the bracket or 0 while the bracket isn't finished
+func (b *Bracket) Champion() int64 {
+	if len(b.Matches) == 0 {
+		return 0
+	}
+
+	last := b.Matches[len(b.Matches)-1]
+	if !last.Decided {
+		return 0
+	}
+
+	return last.Winner
+}
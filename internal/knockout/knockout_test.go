@@ -0,0 +1,122 @@
+package knockout
+
+import (
+	"slices"
+	"testing"
+)
+
+// game is a match that is ready to be played
+type game struct {
+	bracket int
+	player1 int64
+	player2 int64
+}
+
+// result is the winner of a match of the bracket
+type result struct {
+	match  int
+	winner int64
+}
+
+func seeds(count int) []int64 {
+	seeds := make([]int64, count)
+	for i := range seeds {
+		seeds[i] = int64(i + 1)
+	}
+
+	return seeds
+}
+
+func TestBracket(t *testing.T) {
+	tests := []struct {
+		name         string
+		seeds        int
+		double       bool
+		results      []result
+		wantReady    []game
+		wantByes     []int64 // Players who advanced without a game
+		wantChampion int64
+	}{
+		{"two players", 2, false, nil, []game{{BracketWinners, 1, 2}}, nil, 0},
+		{"four players", 4, false, nil, []game{{BracketWinners, 1, 4}, {BracketWinners, 2, 3}}, nil, 0},
+		{"eight players", 8, false, nil, []game{
+			{BracketWinners, 1, 8}, {BracketWinners, 4, 5}, {BracketWinners, 2, 7}, {BracketWinners, 3, 6},
+		}, nil, 0},
+		{"best seeds meet in the final", 4, false, []result{{1, 1}, {2, 2}}, []game{{BracketWinners, 1, 2}}, nil, 0},
+		{"champion", 4, false, []result{{1, 1}, {2, 3}, {3, 3}}, []game{}, nil, 3},
+		{"bye for the best seed", 3, false, nil, []game{{BracketWinners, 2, 3}}, []int64{1}, 0},
+		{"byes for the best seeds", 5, false, nil, []game{{BracketWinners, 4, 5}, {BracketWinners, 2, 3}}, []int64{1, 2, 3}, 0},
+		{"byes for the best two seeds", 6, false, nil, []game{{BracketWinners, 4, 5}, {BracketWinners, 3, 6}}, []int64{1, 2}, 0},
+		{"winner of a game meets the player with a bye", 5, false, []result{{2, 5}}, []game{
+			{BracketWinners, 1, 5}, {BracketWinners, 2, 3},
+		}, []int64{1, 2, 3}, 0},
+		{"losers of the first round meet in the losers bracket", 4, true, []result{{1, 1}, {2, 2}}, []game{
+			{BracketWinners, 1, 2}, {BracketLosers, 4, 3},
+		}, nil, 0},
+		{"loser of the winners final drops to the losers bracket", 4, true, []result{{1, 1}, {2, 2}, {3, 1}, {4, 3}}, []game{
+			{BracketLosers, 3, 2},
+		}, nil, 0},
+		{"losers of the second round drop in reverse order", 8, true, []result{{1, 1}, {2, 4}, {3, 2}, {4, 3}, {5, 1}, {6, 2},
+			{8, 8}, {9, 7}}, []game{{BracketWinners, 1, 2}, {BracketLosers, 8, 3}, {BracketLosers, 7, 4}}, nil, 0},
+		{"grand final", 4, true, []result{{1, 1}, {2, 2}, {3, 1}, {4, 3}, {5, 2}}, []game{{BracketFinal, 1, 2}}, nil, 0},
+		{"grand final won by the champion of the winners bracket", 4, true, []result{{1, 1}, {2, 2}, {3, 1}, {4, 3}, {5, 2},
+			{6, 1}}, []game{}, nil, 1},
+		{"reset grand final", 4, true, []result{{1, 1}, {2, 2}, {3, 1}, {4, 3}, {5, 2}, {6, 2}}, []game{{BracketFinal, 2, 1}}, nil, 0},
+		{"reset grand final won", 4, true, []result{{1, 1}, {2, 2}, {3, 1}, {4, 3}, {5, 2}, {6, 2}, {7, 1}}, []game{}, nil, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bracket := NewBracket(seeds(test.seeds), test.double)
+			for _, r := range test.results {
+				if err := bracket.SetWinner(r.match, r.winner); err != nil {
+					t.Fatalf("SetWinner(%d, %d) = %v", r.match, r.winner, err)
+				}
+			}
+
+			ready := make([]game, 0)
+			for _, match := range bracket.Ready() {
+				ready = append(ready, game{match.Bracket, match.Player1, match.Player2})
+			}
+			if !slices.Equal(ready, test.wantReady) {
+				t.Errorf("Ready() = %v, want %v", ready, test.wantReady)
+			}
+
+			var byes []int64
+			for _, match := range bracket.Matches {
+				if match.Bye && match.Round == 1 && match.Bracket == BracketWinners {
+					byes = append(byes, match.Winner)
+				}
+			}
+			if !slices.Equal(byes, test.wantByes) {
+				t.Errorf("byes = %v, want %v", byes, test.wantByes)
+			}
+
+			if champion := bracket.Champion(); champion != test.wantChampion {
+				t.Errorf("Champion() = %d, want %d", champion, test.wantChampion)
+			}
+		})
+	}
+}
+
+func TestSetWinner(t *testing.T) {
+	tests := []struct {
+		name   string
+		match  int
+		winner int64
+		want   error
+	}{
+		{"winner of the match", 1, 4, nil},
+		{"unknown match", 8, 1, ErrUnknownMatch},
+		{"no match", 0, 1, ErrUnknownMatch},
+		{"player of another match", 1, 2, ErrNotInMatch},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := NewBracket(seeds(4), true).SetWinner(test.match, test.winner); err != test.want {
+				t.Errorf("SetWinner() = %v, want %v", err, test.want)
+			}
+		})
+	}
+}
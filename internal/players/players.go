@@ -3,7 +3,6 @@ package players
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -13,70 +12,84 @@ import (
 	"github.com/jackc/pgx/v5"
 	. "github.comPhantomvv1/SwissPairAPI/internal/auth"
 	. "github.comPhantomvv1/SwissPairAPI/internal/emails"
+	. "github.comPhantomvv1/SwissPairAPI/internal/ratings"
+	. "github.comPhantomvv1/SwissPairAPI/internal/rounds"
 	. "github.comPhantomvv1/SwissPairAPI/internal/tournament"
 )
 
-func GetPlayersForTournamentFromDB(conn *pgx.Conn, tournamentID int) ([]Profile, error) {
-	rows, err := conn.Query(context.Background(), "select user_id from players where tournament_id = $1 order by user_id", tournamentID)
+type PlayerProfile struct {
+	Profile
+	Rating    int    `json:"rating"`
+	Title     string `json:"title,omitempty"`
+	Withdrawn bool   `json:"withdrawn"`
+}
+
+// Titles are the chess titles a player can be registered with
+var Titles = map[string]struct{}{"GM": {}, "IM": {}, "FM": {}, "CM": {}, "WGM": {}, "WIM": {}, "WFM": {}, "WCM": {}}
+
+// GetPlayersForTournamentFromDB returns the players of the tournament ordered by rating
+func GetPlayersForTournamentFromDB(conn *pgx.Conn, tournamentID int) ([]PlayerProfile, error) {
+	rows, err := conn.Query(context.Background(), "select a.id, a.name, a.email, p.rating, p.title, p.withdrawn from players p "+
+		"join authentication a on a.id = p.user_id where p.tournament_id = $1 order by p.rating desc, p.user_id", tournamentID)
 	if err != nil {
 		return nil, err
 	}
 
-	var ids []interface{}
+	var users []PlayerProfile
 	for rows.Next() {
-		id := 0
-		err = rows.Scan(&id)
+		p := PlayerProfile{}
+		var title *string
+		err = rows.Scan(&p.ID, &p.Name, &p.Email, &p.Rating, &title, &p.Withdrawn)
 		if err != nil {
 			return nil, err
 		}
 
-		ids = append(ids, id)
+		if title != nil {
+			p.Title = *title
+		}
+		users = append(users, p)
 	}
 
 	if rows.Err() != nil {
 		return nil, rows.Err()
 	}
 
-	query := "select name, email from authentication where id in ("
-	for i := range ids {
-		if i == len(ids)-1 {
-			query += "$" + fmt.Sprintf("%d", i+1)
-		} else {
-			query += "$" + fmt.Sprintf("%d", i+1) + ", "
-		}
-	}
-	query += ")"
+	return users, nil
+}
 
-	rows, err = conn.Query(context.Background(), query, ids...)
-	if err != nil {
-		return nil, err
-	}
+func CreatePlayersTable(conn *pgx.Conn) error {
+	_, err := conn.Exec(context.Background(), "create table if not exists players (tournament_id int, user_id int, seed int, rating int default 0, "+
+		"title text, withdrawn boolean default false, unique (tournament_id, user_id))")
+	return err
+}
 
-	var users []Profile
-	i := 0
-	for rows.Next() {
-		p := Profile{}
-		err = rows.Scan(&p.Name, &p.Email)
-		if err != nil {
-			return nil, err
-		}
+// withdrawPlayer keeps the player and their games in the tournament, but leaves them out of the next rounds
+func withdrawPlayer(conn *pgx.Conn, tournamentID, userID int) error {
+	_, err := conn.Exec(context.Background(), "update players set withdrawn = true where tournament_id = $1 and user_id = $2",
+		tournamentID, userID)
+	return err
+}
 
-		p.ID = ids[i].(int)
-		i++
-		users = append(users, p)
+// withdrawUser withdraws the user from the tournament. Before the start they have no games to keep, so they leave
+// the tournament as with LeaveTournament, can register for it again and their place goes to the waitlist
+func withdrawUser(conn *pgx.Conn, tournament Tournament, userID int) error {
+	if !tournament.BeforeStart() {
+		return withdrawPlayer(conn, tournament.ID, userID)
 	}
 
-	return users, nil
-}
+	_, err := conn.Exec(context.Background(), "delete from players where tournament_id = $1 and user_id = $2", tournament.ID, userID)
+	if err != nil {
+		return err
+	}
 
-func CreatePlayersTable(conn *pgx.Conn) error {
-	_, err := conn.Exec(context.Background(), "create table if not exists players (tournament_id int, user_id int)")
-	return err
+	return promoteWaitlist(conn, tournament)
 }
 
+// CreatePlayer registers a player for the tournament. Players can join a Swiss tournament that has already started,
+// the rounds they missed count as lost unless they get half-point byes for them
 func CreatePlayer(c *gin.Context) {
 	var information map[string]any
-	json.NewDecoder(c.Request.Body).Decode(&information) // token && playerID && tournamentID
+	json.NewDecoder(c.Request.Body).Decode(&information) // token && playerID && tournamentID && (rating) && (title) && (missedRoundByes)
 
 	token, ok := information["token"].(string)
 	if !ok {
@@ -108,6 +121,30 @@ func CreatePlayer(c *gin.Context) {
 	}
 	userID := int(userIDFl)
 
+	rating := 0
+	if value, has := information["rating"]; has {
+		ratingFl, ok := value.(float64)
+		if !ok || ratingFl < 0 || ratingFl > 4000 {
+			log.Println("Incorrectly provided rating")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided rating"})
+			return
+		}
+		rating = int(ratingFl)
+	}
+
+	var title *string
+	if value, has := information["title"]; has {
+		titleS, ok := value.(string)
+		if _, valid := Titles[titleS]; !ok || !valid {
+			log.Println("Incorrectly provided title")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided title"})
+			return
+		}
+		title = &titleS
+	}
+
+	missedRoundByes, _ := information["missedRoundByes"].(bool)
+
 	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
 	if err != nil {
 		log.Println(err)
@@ -122,20 +159,118 @@ func CreatePlayer(c *gin.Context) {
 		return
 	}
 
-	ownerID, err := GetTournamentOwnerID(conn, tournamentID)
-	if err != nil {
+	if err = CreateRoundsTable(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the table for the rounds"})
+		return
+	}
+
+	if err = CreateRegistrationsTable(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the table for the registrations"})
+		return
+	}
+
+	tournament := Tournament{ID: tournamentID}
+	if err = tournament.GetTournament(conn); err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a tournament with this id doesn't exist"})
+			return
+		}
+
 		log.Println(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error couldn't get the owner of the tournament from the database"})
 		return
 	}
 
-	if accountType != Admin && id != ownerID {
+	if accountType != Admin && id != tournament.OwnerID {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Error only admins and owners can add players"})
 		return
 	}
 
-	_, err = conn.Exec(context.Background(), "insert into players (tournament_id, user_id) values ($1, $2)", tournamentID, userID)
+	if tournament.IsOver() {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrTournamentOver.Error()})
+		return
+	}
+
+	if tournament.TeamBoards != 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error the players of a team tournament enter it with their teams"})
+		return
+	}
+
+	lastRound, err := GetLastRoundNumber(conn, tournamentID)
 	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the current round of the tournament"})
+		return
+	}
+
+	if lastRound > 0 {
+		swissRound, err := IsSwissRound(conn, tournament)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the current round of the tournament"})
+			return
+		}
+
+		if !swissRound {
+			c.JSON(http.StatusConflict, gin.H{"error": "Error players can only join a tournament that has started before its next Swiss round"})
+			return
+		}
+	}
+
+	// Players registered without a rating keep the one from the rating list
+	if _, has := information["rating"]; !has {
+		rating, _, err = GetAccountRating(conn, userID)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the rating of the user"})
+			return
+		}
+	}
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to start a transaction"})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	inserted, err := tx.Exec(context.Background(), "insert into players (tournament_id, user_id, rating, title) values ($1, $2, $3, $4) "+
+		"on conflict (tournament_id, user_id) do nothing", tournamentID, userID, rating, title)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to register the user as a player for your tournament"})
+		return
+	}
+
+	if inserted.RowsAffected() == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Error the user already plays in this tournament"})
+		return
+	}
+
+	// A user added by the owner doesn't wait for approval or a place anymore
+	_, err = tx.Exec(context.Background(), "delete from registrations where tournament_id = $1 and user_id = $2", tournamentID, userID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to remove the registration of the user"})
+		return
+	}
+
+	// The half-point byes of a late entry go after the last board of every round the player missed
+	for round := 1; missedRoundByes && round <= lastRound; round++ {
+		_, err = tx.Exec(context.Background(), "insert into rounds (round, board, pl_1, result, tournament_id) values ($1, (select "+
+			"coalesce(max(board), 0) + 1 from rounds where tournament_id = $2 and round = $1), $3, $4, $2)", round, tournamentID, userID,
+			ResultRequestedBye)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to give byes for the missed rounds"})
+			return
+		}
+	}
+
+	if err = tx.Commit(context.Background()); err != nil {
 		log.Println(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to register the user as a player for your tournament"})
 		return
@@ -212,21 +347,67 @@ func RemoveUserFromTournament(c *gin.Context) {
 	}
 	defer conn.Close(context.Background())
 
-	ownerID, err := GetTournamentOwnerID(conn, tournamentID)
-	if err != nil {
+	tournament := Tournament{ID: tournamentID}
+	if err = tournament.GetTournament(conn); err != nil {
 		log.Println(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error couldn't get the owner from the database"})
 		return
 	}
 
-	if accountType != Admin && id != ownerID {
+	if accountType != Admin && id != tournament.OwnerID {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Error only admins and the owner of the tournament can remove users from the tournament"})
 		return
 	}
 
-	check := 0
-	err = conn.QueryRow(context.Background(), "delete from players where tournament_id = $1 and user_id = $2",
-		tournamentID, userID).Scan(&check)
+	if tournament.IsOver() {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrTournamentOver.Error()})
+		return
+	}
+
+	if err = CreateRoundsTable(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the table for the rounds"})
+		return
+	}
+
+	if tournament.Status == StatusInProgress {
+		lastRound, err := GetLastRoundNumber(conn, tournamentID)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the current round of the tournament"})
+			return
+		}
+
+		finished, err := IsRoundFinished(conn, tournamentID, lastRound)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to check if the round is finished"})
+			return
+		}
+
+		if !finished {
+			c.JSON(http.StatusConflict, gin.H{"error": "Error players can't be removed while a round is being played"})
+			return
+		}
+	}
+
+	// Players who already have games withdraw instead, so that their games stay in the tournament
+	games := 0
+	err = conn.QueryRow(context.Background(), "select count(*) from rounds where tournament_id = $1 and (pl_1 = $2 or pl_2 = $2)",
+		tournamentID, userID).Scan(&games)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the games of the user"})
+		return
+	}
+
+	if games > 0 {
+		err = withdrawPlayer(conn, tournamentID, userID)
+	} else {
+		check := 0
+		err = conn.QueryRow(context.Background(), "delete from players where tournament_id = $1 and user_id = $2 returning user_id",
+			tournamentID, userID).Scan(&check)
+	}
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Error there is no user with this id playing in this tournament"})
@@ -260,5 +441,112 @@ func RemoveUserFromTournament(c *gin.Context) {
 		return
 	}
 
+	if tournament.BeforeStart() {
+		if err = promoteWaitlist(conn, tournament); err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to give the place of the user to the waitlist"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, nil)
+}
+
+// WithdrawPlayer lets a player (or the owner of the tournament on their behalf) withdraw from the tournament.
+// The games they played still count, but they aren't paired for the next rounds. Unlike removing a player it's
+// allowed while a round is being played, the withdrawal starts with the next round so the current game keeps
+// both players and takes its result as usual
+func WithdrawPlayer(c *gin.Context) {
+	var information map[string]any
+	json.NewDecoder(c.Request.Body).Decode(&information) // token && tournamentID && (userID)
+
+	token, ok := information["token"].(string)
+	if !ok {
+		log.Println("Incorrectly provided token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided token"})
+		return
+	}
+
+	id, accountType, err := ValidateJWT(token)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Error invalid token"})
+		return
+	}
+
+	tournamentIDFl, ok := information["tournamentID"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided id of the tournament")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return
+	}
+	tournamentID := int(tournamentIDFl)
+
+	userID := id
+	if userIDFl, ok := information["userID"].(float64); ok {
+		userID = int(userIDFl)
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if err = CreateRoundsTable(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the table for the rounds"})
+		return
+	}
+
+	tournament := Tournament{ID: tournamentID}
+	if err = tournament.GetTournament(conn); err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a tournament with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the information about the tournament"})
+		return
+	}
+
+	if userID != id && accountType != Admin && id != tournament.OwnerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Error only admins and the owner of the tournament can withdraw other players"})
+		return
+	}
+
+	if tournament.IsOver() {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrTournamentOver.Error()})
+		return
+	}
+
+	withdrawn := false
+	err = conn.QueryRow(context.Background(), "select withdrawn from players where tournament_id = $1 and user_id = $2",
+		tournamentID, userID).Scan(&withdrawn)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error the user doesn't play in this tournament"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to check the players of the tournament"})
+		return
+	}
+
+	if withdrawn {
+		c.JSON(http.StatusConflict, gin.H{"error": "Error the player has already withdrawn from the tournament"})
+		return
+	}
+
+	if err = withdrawUser(conn, tournament, userID); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to withdraw the player from the tournament"})
+		return
+	}
+
 	c.JSON(http.StatusOK, nil)
 }
@@ -0,0 +1,602 @@
+package players
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	. "github.comPhantomvv1/SwissPairAPI/internal/auth"
+	. "github.comPhantomvv1/SwissPairAPI/internal/emails"
+	. "github.comPhantomvv1/SwissPairAPI/internal/ratings"
+	. "github.comPhantomvv1/SwissPairAPI/internal/tournament"
+)
+
+// The statuses of the users who registered themselves but don't play in the tournament yet
+const (
+	RegistrationPending    = 1 // Waiting for the approval of the owner
+	RegistrationWaitlisted = 2 // Waiting for a place in the tournament
+)
+
+type Registration struct {
+	UserID    int       `json:"user_id"`
+	Name      string    `json:"name"`
+	Status    int       `json:"status"`
+	Position  int       `json:"position,omitempty"` // Position on the waitlist
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateRegistrationsTable creates the table of the users waiting for approval or for a place in a tournament.
+// The waitlist is ordered by the time the users were put on it
+func CreateRegistrationsTable(conn *pgx.Conn) error {
+	_, err := conn.Exec(context.Background(), "create table if not exists registrations (id serial primary key, tournament_id int, "+
+		"user_id int, status int, created_at timestamp default current_timestamp, updated_at timestamp default current_timestamp, "+
+		"unique (tournament_id, user_id))")
+	return err
+}
+
+// isRegistered reports whether the user plays in the tournament or has registered for it
+func isRegistered(conn *pgx.Conn, tournamentID, userID int) (bool, error) {
+	registered := false
+	err := conn.QueryRow(context.Background(), "select exists (select 1 from players where tournament_id = $1 and user_id = $2) or "+
+		"exists (select 1 from registrations where tournament_id = $1 and user_id = $2)", tournamentID, userID).Scan(&registered)
+	return registered, err
+}
+
+// placeUser registers the user as a player when the tournament has a free place and puts them on the waitlist otherwise.
+// The tournament is locked while its players are counted, so that two users can't take the last place at the same time
+func placeUser(conn *pgx.Conn, tournament Tournament, userID int) (bool, error) {
+	rating, _, err := GetAccountRating(conn, userID)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(context.Background())
+
+	locked := 0
+	err = tx.QueryRow(context.Background(), "select id from tournaments where id = $1 for update", tournament.ID).Scan(&locked)
+	if err != nil {
+		return false, err
+	}
+
+	// A user who already plays, for example added by the owner in the meantime, only leaves the list
+	players, playing := 0, false
+	err = tx.QueryRow(context.Background(), "select count(*) filter (where not withdrawn), count(*) filter (where user_id = $2) > 0 "+
+		"from players where tournament_id = $1", tournament.ID, userID).Scan(&players, &playing)
+	if err != nil {
+		return false, err
+	}
+
+	if !playing && tournament.MaxPlayers != 0 && players >= tournament.MaxPlayers {
+		// Users who are already on the waitlist keep their place on it
+		_, err = tx.Exec(context.Background(), "insert into registrations (tournament_id, user_id, status) values ($1, $2, $3) "+
+			"on conflict (tournament_id, user_id) do update set status = excluded.status, updated_at = current_timestamp "+
+			"where registrations.status <> excluded.status", tournament.ID, userID, RegistrationWaitlisted)
+		if err != nil {
+			return false, err
+		}
+
+		return true, tx.Commit(context.Background())
+	}
+
+	_, err = tx.Exec(context.Background(), "delete from registrations where tournament_id = $1 and user_id = $2", tournament.ID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(context.Background(), "insert into players (tournament_id, user_id, rating) values ($1, $2, $3) "+
+		"on conflict (tournament_id, user_id) do nothing", tournament.ID, userID, rating)
+	if err != nil {
+		return false, err
+	}
+
+	return false, tx.Commit(context.Background())
+}
+
+// waitlistPosition returns the place of the user on the waitlist of the tournament, starting from 1
+func waitlistPosition(conn *pgx.Conn, tournamentID, userID int) (int, error) {
+	position := 0
+	err := conn.QueryRow(context.Background(), "select count(*) from registrations r, registrations u where r.tournament_id = $1 "+
+		"and r.status = $2 and u.tournament_id = $1 and u.user_id = $3 and (r.updated_at, r.id) <= (u.updated_at, u.id)",
+		tournamentID, RegistrationWaitlisted, userID).Scan(&position)
+	return position, err
+}
+
+// promoteWaitlist gives the free places of the tournament to the users on its waitlist in the order they were put on it
+func promoteWaitlist(conn *pgx.Conn, tournament Tournament) error {
+	if err := CreateRegistrationsTable(conn); err != nil {
+		return err
+	}
+
+	for {
+		userID, userEmail := 0, ""
+		err := conn.QueryRow(context.Background(), "select r.user_id, a.email from registrations r join authentication a on a.id = r.user_id "+
+			"where r.tournament_id = $1 and r.status = $2 order by r.updated_at, r.id limit 1", tournament.ID, RegistrationWaitlisted).Scan(&userID,
+			&userEmail)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return nil
+			}
+
+			return err
+		}
+
+		waitlisted, err := placeUser(conn, tournament, userID)
+		if err != nil || waitlisted {
+			return err
+		}
+
+		if err = RegisteredEmail(userEmail, tournament.Name); err != nil {
+			return err
+		}
+	}
+}
+
+// RegisterForTournament lets the user register themselves for a tournament with an open registration. When the owner approves
+// the players their registration waits for it and when the tournament is full they are put on its waitlist
+func RegisterForTournament(c *gin.Context) {
+	var information map[string]any
+	json.NewDecoder(c.Request.Body).Decode(&information) // token && tournamentID
+
+	token, ok := information["token"].(string)
+	if !ok {
+		log.Println("Incorrectly provided token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided token"})
+		return
+	}
+
+	id, _, err := ValidateJWT(token)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Error invalid token"})
+		return
+	}
+
+	tournamentIDFl, ok := information["tournamentID"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided id of the tournament")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return
+	}
+	tournamentID := int(tournamentIDFl)
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if err = CreatePlayersTable(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error couldn't create the table for the players"})
+		return
+	}
+
+	if err = CreateRegistrationsTable(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the table for the registrations"})
+		return
+	}
+
+	tournament := Tournament{ID: tournamentID}
+	if err = tournament.GetTournament(conn); err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a tournament with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the information about the tournament"})
+		return
+	}
+
+	if tournament.Status != StatusRegistrationOpen {
+		c.JSON(http.StatusConflict, gin.H{"error": "Error the registration of the tournament isn't open"})
+		return
+	}
+
+	if tournament.TeamBoards != 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error the players of a team tournament enter it with their teams"})
+		return
+	}
+
+	registered, err := isRegistered(conn, tournamentID, id)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to check the players of the tournament"})
+		return
+	}
+
+	if registered {
+		c.JSON(http.StatusConflict, gin.H{"error": "Error you have already registered for this tournament"})
+		return
+	}
+
+	userEmail := ""
+	err = conn.QueryRow(context.Background(), "select email from authentication a where a.id = $1", id).Scan(&userEmail)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get your email from the database"})
+		return
+	}
+
+	if tournament.Approval {
+		inserted, err := conn.Exec(context.Background(), "insert into registrations (tournament_id, user_id, status) values ($1, $2, $3) "+
+			"on conflict (tournament_id, user_id) do nothing", tournamentID, id, RegistrationPending)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to register you for the tournament"})
+			return
+		}
+
+		// Another request of the user registered them in the meantime
+		if inserted.RowsAffected() == 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Error you have already registered for this tournament"})
+			return
+		}
+
+		if err = ApprovalPendingEmail(userEmail, tournament.Name); err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error sending you an email"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"status": RegistrationPending})
+		return
+	}
+
+	waitlisted, err := placeUser(conn, tournament, id)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to register you for the tournament"})
+		return
+	}
+
+	if waitlisted {
+		respondWaitlisted(c, conn, tournament, id, userEmail)
+		return
+	}
+
+	if err = RegisteredEmail(userEmail, tournament.Name); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error sending you an email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, nil)
+}
+
+// respondWaitlisted tells the user where they are on the waitlist of the tournament
+func respondWaitlisted(c *gin.Context, conn *pgx.Conn, tournament Tournament, userID int, userEmail string) {
+	position, err := waitlistPosition(conn, tournament.ID, userID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the position on the waitlist"})
+		return
+	}
+
+	if err = WaitlistedEmail(userEmail, tournament.Name, position); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error sending an email to the user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": RegistrationWaitlisted, "position": position})
+}
+
+// ApproveRegistration lets the owner of the tournament approve or reject the registration of a user. An approved user
+// plays in the tournament or is put on its waitlist when it is full
+func ApproveRegistration(c *gin.Context) {
+	var information map[string]any
+	json.NewDecoder(c.Request.Body).Decode(&information) // token && tournamentID && userID && (approve)
+
+	token, ok := information["token"].(string)
+	if !ok {
+		log.Println("Incorrectly provided token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided token"})
+		return
+	}
+
+	id, accountType, err := ValidateJWT(token)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Error invalid token"})
+		return
+	}
+
+	tournamentIDFl, ok := information["tournamentID"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided id of the tournament")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return
+	}
+	tournamentID := int(tournamentIDFl)
+
+	userIDFl, ok := information["userID"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided id of the user")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the user"})
+		return
+	}
+	userID := int(userIDFl)
+
+	approve := true
+	if value, has := information["approve"]; has {
+		approve, ok = value.(bool)
+		if !ok {
+			log.Println("Incorrectly provided approval")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided approval"})
+			return
+		}
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if err = CreatePlayersTable(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error couldn't create the table for the players"})
+		return
+	}
+
+	if err = CreateRegistrationsTable(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the table for the registrations"})
+		return
+	}
+
+	tournament := Tournament{ID: tournamentID}
+	if err = tournament.GetTournament(conn); err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a tournament with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the information about the tournament"})
+		return
+	}
+
+	if accountType != Admin && id != tournament.OwnerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Error only admins and the owner of the tournament can approve registrations"})
+		return
+	}
+
+	if !tournament.BeforeStart() {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrAlreadyStarted.Error()})
+		return
+	}
+
+	status, userEmail := 0, ""
+	err = conn.QueryRow(context.Background(), "select r.status, a.email from registrations r join authentication a on a.id = r.user_id "+
+		"where r.tournament_id = $1 and r.user_id = $2", tournamentID, userID).Scan(&status, &userEmail)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error the user hasn't registered for this tournament"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the registration of the user"})
+		return
+	}
+
+	if status != RegistrationPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "Error the registration of the user isn't waiting for approval"})
+		return
+	}
+
+	if !approve {
+		_, err = conn.Exec(context.Background(), "delete from registrations where tournament_id = $1 and user_id = $2", tournamentID, userID)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to reject the registration of the user"})
+			return
+		}
+
+		if err = RegistrationRejectedEmail(userEmail, tournament.Name); err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error sending an email to the user"})
+			return
+		}
+
+		c.JSON(http.StatusOK, nil)
+		return
+	}
+
+	waitlisted, err := placeUser(conn, tournament, userID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to register the user for the tournament"})
+		return
+	}
+
+	if waitlisted {
+		respondWaitlisted(c, conn, tournament, userID, userEmail)
+		return
+	}
+
+	if err = RegisteredEmail(userEmail, tournament.Name); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error sending an email to the user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, nil)
+}
+
+// LeaveTournament lets the user take back their registration before the tournament starts. The place they
+// leave goes to the first user on the waitlist
+func LeaveTournament(c *gin.Context) {
+	var information map[string]any
+	json.NewDecoder(c.Request.Body).Decode(&information) // token && tournamentID
+
+	token, ok := information["token"].(string)
+	if !ok {
+		log.Println("Incorrectly provided token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided token"})
+		return
+	}
+
+	id, _, err := ValidateJWT(token)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Error invalid token"})
+		return
+	}
+
+	tournamentIDFl, ok := information["tournamentID"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided id of the tournament")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return
+	}
+	tournamentID := int(tournamentIDFl)
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if err = CreatePlayersTable(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error couldn't create the table for the players"})
+		return
+	}
+
+	if err = CreateRegistrationsTable(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the table for the registrations"})
+		return
+	}
+
+	tournament := Tournament{ID: tournamentID}
+	if err = tournament.GetTournament(conn); err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a tournament with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the information about the tournament"})
+		return
+	}
+
+	if !tournament.BeforeStart() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Error the tournament has already started, withdraw from it instead"})
+		return
+	}
+
+	// Users still waiting only leave the list, players free their place for the waitlist
+	status := 0
+	err = conn.QueryRow(context.Background(), "delete from registrations where tournament_id = $1 and user_id = $2 returning status",
+		tournamentID, id).Scan(&status)
+	if err == nil {
+		c.JSON(http.StatusOK, nil)
+		return
+	}
+	if err != pgx.ErrNoRows {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to remove your registration"})
+		return
+	}
+
+	check := 0
+	err = conn.QueryRow(context.Background(), "delete from players where tournament_id = $1 and user_id = $2 returning user_id",
+		tournamentID, id).Scan(&check)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error you haven't registered for this tournament"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to remove your registration"})
+		return
+	}
+
+	if err = promoteWaitlist(conn, tournament); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to give your place to the waitlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, nil)
+}
+
+// GetRegistrations returns the users waiting for approval and the waitlist of the tournament in its order
+func GetRegistrations(c *gin.Context) {
+	tournamentID, err := strconv.Atoi(c.Param("tournamentID"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if err = CreateRegistrationsTable(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the table for the registrations"})
+		return
+	}
+
+	rows, err := conn.Query(context.Background(), "select r.user_id, a.name, r.status, r.created_at from registrations r join authentication a "+
+		"on a.id = r.user_id where r.tournament_id = $1 order by r.status, r.updated_at, r.id", tournamentID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the registrations of the tournament"})
+		return
+	}
+	defer rows.Close()
+
+	registrations := []Registration{}
+	position := 0
+	for rows.Next() {
+		r := Registration{}
+		if err = rows.Scan(&r.UserID, &r.Name, &r.Status, &r.CreatedAt); err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the registrations of the tournament"})
+			return
+		}
+
+		if r.Status == RegistrationWaitlisted {
+			position++
+			r.Position = position
+		}
+		registrations = append(registrations, r)
+	}
+
+	if rows.Err() != nil {
+		log.Println(rows.Err())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the registrations of the tournament"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"registrations": registrations})
+}
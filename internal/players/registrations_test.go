@@ -0,0 +1,80 @@
+package players
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	. "github.comPhantomvv1/SwissPairAPI/internal/auth"
+	. "github.comPhantomvv1/SwissPairAPI/internal/tournament"
+)
+
+// A player who withdraws before the start can register for the tournament again
+func TestWithdrawAndRegisterAgain(t *testing.T) {
+	if os.Getenv("DATABASE_URL") == "" {
+		t.Skip("DATABASE_URL isn't set")
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close(context.Background())
+
+	for _, create := range []func(*pgx.Conn) error{CreateAuthTable, CreateTournamentsTable, CreatePlayersTable, CreateRegistrationsTable} {
+		if err = create(conn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	userID := 0
+	err = conn.QueryRow(context.Background(), "insert into authentication (name, email, password, type) values ($1, $2, '', $3) "+
+		"returning id", "Withdrawing player", "withdrawing.player@example.com", User).Scan(&userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tournament := Tournament{Name: "Withdrawal test", Status: StatusRegistrationOpen}
+	err = conn.QueryRow(context.Background(), "insert into tournaments (name, owner_id, status) values ($1, $2, $3) returning id",
+		tournament.Name, userID, tournament.Status).Scan(&tournament.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		for _, query := range []string{"delete from registrations where tournament_id = $1", "delete from players where tournament_id = $1",
+			"delete from tournaments where id = $1"} {
+			conn.Exec(context.Background(), query, tournament.ID)
+		}
+		conn.Exec(context.Background(), "delete from authentication where id = $1", userID)
+	}()
+
+	if waitlisted, err := placeUser(conn, tournament, userID); err != nil || waitlisted {
+		t.Fatalf("placeUser() = %t, %v, want false, nil", waitlisted, err)
+	}
+
+	if err = withdrawUser(conn, tournament, userID); err != nil {
+		t.Fatalf("withdrawUser() = %v", err)
+	}
+
+	registered, err := isRegistered(conn, tournament.ID, userID)
+	if err != nil || registered {
+		t.Fatalf("isRegistered() after withdrawing = %t, %v, want false, nil", registered, err)
+	}
+
+	if waitlisted, err := placeUser(conn, tournament, userID); err != nil || waitlisted {
+		t.Fatalf("placeUser() after withdrawing = %t, %v, want false, nil", waitlisted, err)
+	}
+
+	players, active := 0, false
+	err = conn.QueryRow(context.Background(), "select count(*), coalesce(bool_and(not withdrawn), false) from players "+
+		"where tournament_id = $1 and user_id = $2", tournament.ID, userID).Scan(&players, &active)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if players != 1 || !active {
+		t.Errorf("players after registering again = %d, active %t, want 1, true", players, active)
+	}
+}
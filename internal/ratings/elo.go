@@ -0,0 +1,103 @@
+package ratings
+
+import (
+	"context"
+	"math"
+
+	"github.com/jackc/pgx/v5"
+	. "github.comPhantomvv1/SwissPairAPI/internal/rounds"
+	. "github.comPhantomvv1/SwissPairAPI/internal/tournament"
+)
+
+// DefaultRating is the rating of the players who don't have one yet
+const DefaultRating = 1500
+
+type RatingChange struct {
+	TournamentID int     `json:"tournament_id,omitempty"`
+	PlayerID     int     `json:"player_id"`
+	RatingBefore int     `json:"rating_before"`
+	Games        int     `json:"games"`
+	Score        float64 `json:"score"`
+	Expected     float64 `json:"expected"`
+	Change       float64 `json:"change"`
+	RatingAfter  int     `json:"rating_after"`
+}
+
+// ExpectedScore returns the score a player is expected to make against the opponent
+func ExpectedScore(rating, opponent int) float64 {
+	return 1 / (1 + math.Pow(10, float64(opponent-rating)/400))
+}
+
+// KFactor returns the K-factor of the player. Without a fixed K-factor for the tournament it's 40 for the players
+// with less than 30 rated games, 20 for the players below 2400 and 10 for the rest
+func KFactor(tournament Tournament, rating, games int) int {
+	switch {
+	case tournament.KFactor != 0:
+		return tournament.KFactor
+	case games < 30:
+		return 40
+	case rating < 2400:
+		return 20
+	default:
+		return 10
+	}
+}
+
+// CalculateElo calculates the rating changes of the players from the played games of the tournament. Byes and
+// unplayed games don't change the rating and the games count with their chess score whatever the scoring of the tournament
+func CalculateElo(tournament Tournament, playerIDs []int, ratings, ratedGames map[int]int, rounds []Round) []RatingChange {
+	rating := func(id int) int {
+		if ratings[id] == 0 {
+			return DefaultRating
+		}
+
+		return ratings[id]
+	}
+
+	changes := make([]RatingChange, 0, len(playerIDs))
+	for _, id := range playerIDs {
+		change := RatingChange{PlayerID: id, RatingBefore: rating(id)}
+		for _, round := range rounds {
+			if round.Player1ID != id && round.Player2ID != id {
+				continue
+			}
+
+			if !round.IsPlayed() || round.Result == 0 {
+				continue
+			}
+
+			change.Games++
+			change.Score += round.GameScore(id)
+			change.Expected += ExpectedScore(change.RatingBefore, rating(round.Opponent(id)))
+		}
+
+		k := KFactor(tournament, change.RatingBefore, ratedGames[id])
+		change.Change = math.Round(float64(k)*(change.Score-change.Expected)*10) / 10
+		change.Expected = math.Round(change.Expected*100) / 100
+		change.RatingAfter = int(math.Round(float64(change.RatingBefore) + change.Change))
+
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+// saveElo stores the rating changes of the tournament and the new ratings in the rating list
+func saveElo(tx pgx.Tx, tournamentID int, changes []RatingChange) error {
+	for _, change := range changes {
+		_, err := tx.Exec(context.Background(), "insert into rating_changes (tournament_id, user_id, rating_before, games, score, expected, "+
+			"change, rating_after) values ($1, $2, $3, $4, $5, $6, $7, $8)", tournamentID, change.PlayerID, change.RatingBefore, change.Games,
+			change.Score, change.Expected, change.Change, change.RatingAfter)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(context.Background(), "insert into ratings (user_id, rating, games) values ($1, $2, $3) on conflict (user_id) "+
+			"do update set rating = excluded.rating, games = ratings.games + $3", change.PlayerID, change.RatingAfter, change.Games)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,241 @@
+package ratings
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	. "github.comPhantomvv1/SwissPairAPI/internal/rounds"
+	. "github.comPhantomvv1/SwissPairAPI/internal/tournament"
+)
+
+const (
+	DefaultDeviation  = 350.0
+	DefaultVolatility = 0.06
+
+	glickoScale     = 173.7178 // Converts between the Glicko and the Glicko-2 scale
+	glickoTau       = 0.5      // Constrains the change of the volatility over time
+	glickoTolerance = 0.000001
+)
+
+// GlickoRating is the Glicko-2 rating of a player on the Glicko scale
+type GlickoRating struct {
+	Rating     float64 `json:"rating"`
+	Deviation  float64 `json:"deviation"`
+	Volatility float64 `json:"volatility"`
+
+	period *time.Time // Last rating period the rating was updated for, nil before the periods were kept
+}
+
+type GlickoChange struct {
+	TournamentID int          `json:"tournament_id,omitempty"`
+	PlayerID     int          `json:"player_id"`
+	Games        int          `json:"games"`
+	Score        float64      `json:"score"`
+	Before       GlickoRating `json:"before"`
+	After        GlickoRating `json:"after"`
+}
+
+// NewGlickoRating returns the rating of a player without Glicko-2 games, starting from the given rating when there is one
+func NewGlickoRating(rating int) GlickoRating {
+	if rating == 0 {
+		rating = DefaultRating
+	}
+
+	return GlickoRating{Rating: float64(rating), Deviation: DefaultDeviation, Volatility: DefaultVolatility}
+}
+
+// inactive returns the rating after a rating period without games, only the deviation grows
+func (g GlickoRating) inactive() GlickoRating {
+	phi := g.Deviation / glickoScale
+	g.Deviation = min(math.Sqrt(phi*phi+g.Volatility*g.Volatility)*glickoScale, DefaultDeviation)
+	return g
+}
+
+// ratingPeriod returns the calendar month of the time, which is the rating period of the deviation of the users who don't play
+func ratingPeriod(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// periodsBetween returns the number of rating periods from the first one to the second one
+func periodsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
+
+// glickoG reduces the impact of a game according to the deviation of the opponent
+func glickoG(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+// update returns the rating after a rating period with the given opponents and scores, following
+// Glickman, "Example of the Glicko-2 system" (2013)
+func (g GlickoRating) update(opponents []GlickoRating, scores []float64) GlickoRating {
+	if len(opponents) == 0 {
+		return g.inactive()
+	}
+
+	mu, phi, sigma := (g.Rating-DefaultRating)/glickoScale, g.Deviation/glickoScale, g.Volatility
+
+	// Estimated variance of the rating from the game outcomes and the estimated improvement
+	variance, improvement := 0.0, 0.0
+	for i, opponent := range opponents {
+		muJ, phiJ := (opponent.Rating-DefaultRating)/glickoScale, opponent.Deviation/glickoScale
+		gJ := glickoG(phiJ)
+		expected := 1 / (1 + math.Exp(-gJ*(mu-muJ)))
+
+		variance += gJ * gJ * expected * (1 - expected)
+		improvement += gJ * (scores[i] - expected)
+	}
+	variance = 1 / variance
+	delta := variance * improvement
+
+	// New volatility with the Illinois algorithm
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		return ex*(delta*delta-phi*phi-variance-ex)/(2*math.Pow(phi*phi+variance+ex, 2)) - (x-a)/(glickoTau*glickoTau)
+	}
+
+	lower := a
+	var upper float64
+	if delta*delta > phi*phi+variance {
+		upper = math.Log(delta*delta - phi*phi - variance)
+	} else {
+		k := 1.0
+		for f(a-k*glickoTau) < 0 {
+			k++
+		}
+		upper = a - k*glickoTau
+	}
+
+	fLower, fUpper := f(lower), f(upper)
+	for math.Abs(upper-lower) > glickoTolerance {
+		next := lower + (lower-upper)*fLower/(fUpper-fLower)
+		fNext := f(next)
+		if fNext*fUpper <= 0 {
+			lower, fLower = upper, fUpper
+		} else {
+			fLower /= 2
+		}
+		upper, fUpper = next, fNext
+	}
+	newSigma := math.Exp(lower / 2)
+
+	phiStar := math.Sqrt(phi*phi + newSigma*newSigma)
+	newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/variance)
+	newMu := mu + newPhi*newPhi*improvement
+
+	return GlickoRating{Rating: newMu*glickoScale + DefaultRating, Deviation: newPhi * glickoScale, Volatility: newSigma}
+}
+
+// CalculateGlicko rates the tournament as one rating period. All the ratings of the period use the ratings of the
+// players before it. Byes and unplayed games aren't part of the period
+func CalculateGlicko(tournament Tournament, playerIDs []int, current map[int]GlickoRating, rounds []Round) []GlickoChange {
+	changes := make([]GlickoChange, 0, len(playerIDs))
+	for _, id := range playerIDs {
+		change := GlickoChange{PlayerID: id, Before: current[id]}
+
+		var opponents []GlickoRating
+		var scores []float64
+		for _, round := range rounds {
+			if round.Player1ID != id && round.Player2ID != id {
+				continue
+			}
+
+			if !round.IsPlayed() || round.Result == 0 {
+				continue
+			}
+
+			points := round.GameScore(id)
+			opponents = append(opponents, current[round.Opponent(id)])
+			scores = append(scores, points)
+			change.Games++
+			change.Score += points
+		}
+
+		change.After = change.Before.update(opponents, scores)
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+// GetGlickoRatings returns the Glicko-2 ratings of all the rated users
+func GetGlickoRatings(conn *pgx.Conn) (map[int]GlickoRating, error) {
+	rows, err := conn.Query(context.Background(), "select user_id, rating, deviation, volatility, period from glicko_ratings")
+	if err != nil {
+		return nil, err
+	}
+
+	ratings := make(map[int]GlickoRating)
+	for rows.Next() {
+		id, rating := 0, GlickoRating{}
+		err = rows.Scan(&id, &rating.Rating, &rating.Deviation, &rating.Volatility, &rating.period)
+		if err != nil {
+			return nil, err
+		}
+
+		ratings[id] = rating
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return ratings, nil
+}
+
+// saveGlicko stores the changes of the rating period. The deviation of the rated users who didn't play grows once for every
+// calendar month since their rating was last updated, however many tournaments finish in the meantime
+func saveGlicko(tx pgx.Tx, tournamentID int, changes []GlickoChange, all map[int]GlickoRating) error {
+	period := ratingPeriod(time.Now())
+	played := make(map[int]struct{})
+	for _, change := range changes {
+		played[change.PlayerID] = struct{}{}
+
+		_, err := tx.Exec(context.Background(), "insert into glicko_history (tournament_id, user_id, games, score, rating_before, "+
+			"deviation_before, rating, deviation, volatility) values ($1, $2, $3, $4, $5, $6, $7, $8, $9)", tournamentID, change.PlayerID,
+			change.Games, change.Score, change.Before.Rating, change.Before.Deviation, change.After.Rating, change.After.Deviation,
+			change.After.Volatility)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(context.Background(), "insert into glicko_ratings (user_id, rating, deviation, volatility, period) values ($1, $2, $3, "+
+			"$4, $5) on conflict (user_id) do update set rating = excluded.rating, deviation = excluded.deviation, volatility = excluded.volatility, "+
+			"period = excluded.period", change.PlayerID, change.After.Rating, change.After.Deviation, change.After.Volatility, period)
+		if err != nil {
+			return err
+		}
+	}
+
+	for id, rating := range all {
+		if _, has := played[id]; has {
+			continue
+		}
+
+		// Ratings from before the periods were kept start counting from this one
+		missed := 0
+		if rating.period != nil {
+			missed = periodsBetween(*rating.period, period)
+			if missed <= 0 {
+				continue
+			}
+		}
+
+		for range missed {
+			rating = rating.inactive()
+		}
+
+		// A tournament finishing at the same time may have already updated the rating for this period
+		_, err := tx.Exec(context.Background(), "update glicko_ratings set deviation = $1, period = $2 where user_id = $3 and "+
+			"period is not distinct from $4", rating.Deviation, period, id, rating.period)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,466 @@
+package ratings
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	. "github.comPhantomvv1/SwissPairAPI/internal/auth"
+	. "github.comPhantomvv1/SwissPairAPI/internal/rounds"
+	. "github.comPhantomvv1/SwissPairAPI/internal/tournament"
+)
+
+type LeaderboardEntry struct {
+	Rank      int     `json:"rank"`
+	PlayerID  int     `json:"player_id"`
+	Name      string  `json:"name"`
+	Rating    float64 `json:"rating"`
+	Deviation float64 `json:"deviation,omitempty"`
+}
+
+func CreateRatingTables(conn *pgx.Conn) error {
+	_, err := conn.Exec(context.Background(), "create table if not exists ratings (user_id int primary key references authentication(id), "+
+		"rating int, games int default 0)")
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Exec(context.Background(), "create table if not exists rating_changes (tournament_id int references tournaments(id), "+
+		"user_id int references authentication(id), rating_before int, games int, score real, expected real, change real, rating_after int, "+
+		"unique (tournament_id, user_id))")
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Exec(context.Background(), "create table if not exists glicko_ratings (user_id int primary key references authentication(id), "+
+		"rating double precision, deviation double precision, volatility double precision, period date)")
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Exec(context.Background(), "create table if not exists glicko_history (tournament_id int references tournaments(id), "+
+		"user_id int references authentication(id), games int, score real, rating_before double precision, deviation_before double precision, "+
+		"rating double precision, deviation double precision, volatility double precision, unique (tournament_id, user_id))")
+
+	return err
+}
+
+// GetAccountRating returns the rating of the user from the rating list and the number of rated games behind it.
+// Users without an Elo rating get their Glicko-2 rating and users without either get 0
+func GetAccountRating(conn *pgx.Conn, userID int) (int, int, error) {
+	if err := CreateRatingTables(conn); err != nil {
+		return 0, 0, err
+	}
+
+	rating, games := 0, 0
+	err := conn.QueryRow(context.Background(), "select rating, games from ratings where user_id = $1", userID).Scan(&rating, &games)
+	if err != pgx.ErrNoRows {
+		return rating, games, err
+	}
+
+	glicko := 0.0
+	err = conn.QueryRow(context.Background(), "select rating from glicko_ratings where user_id = $1", userID).Scan(&glicko)
+	if err == pgx.ErrNoRows {
+		return 0, 0, nil
+	}
+
+	return int(math.Round(glicko)), 0, err
+}
+
+// FinishTournament closes a tournament whose games all have results and rates it with its rating system
+func FinishTournament(c *gin.Context) {
+	var information map[string]any
+	json.NewDecoder(c.Request.Body).Decode(&information) // token && tournamentID
+
+	token, ok := information["token"].(string)
+	if !ok {
+		log.Println("Incorrectly provided token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided token"})
+		return
+	}
+
+	id, accType, err := ValidateJWT(token)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Error invalid token"})
+		return
+	}
+
+	tournamentIDFl, ok := information["tournamentID"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided id of the tournament")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return
+	}
+	tournamentID := int(tournamentIDFl)
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if err = CreateRoundsTable(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the table for the rounds"})
+		return
+	}
+
+	if err = CreateRatingTables(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the tables for the ratings"})
+		return
+	}
+
+	tournament := Tournament{ID: tournamentID}
+	if err = tournament.GetTournament(conn); err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a tournament with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the information about the tournament"})
+		return
+	}
+
+	if accType != Admin && id != tournament.OwnerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Error only admins and the owner of the tournament can finish it"})
+		return
+	}
+
+	if !CanTransition(tournament.Status, StatusFinished) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Error only tournaments that are in progress can be finished"})
+		return
+	}
+
+	unfinished, err := GetUnfinishedGames(conn, tournamentID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to check the results of the tournament"})
+		return
+	}
+
+	if unfinished > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Error not all the results of the tournament have been entered"})
+		return
+	}
+
+	playerIDs, err := GetPlayerIDs(conn, tournamentID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the players of the tournament"})
+		return
+	}
+
+	ratings, err := GetPlayerRatings(conn, tournamentID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the ratings of the players"})
+		return
+	}
+
+	rounds, _, err := GetPublishedRounds(conn, tournamentID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the rounds"})
+		return
+	}
+
+	var eloChanges []RatingChange
+	var glickoChanges []GlickoChange
+	var glickoRatings map[int]GlickoRating
+	if tournament.RatingSystem == RatingGlicko2 {
+		glickoRatings, err = GetGlickoRatings(conn)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the rating list"})
+			return
+		}
+
+		current := make(map[int]GlickoRating)
+		for _, playerID := range playerIDs {
+			rating, has := glickoRatings[playerID]
+			if !has {
+				rating = NewGlickoRating(ratings[playerID])
+			}
+			current[playerID] = rating
+		}
+
+		glickoChanges = CalculateGlicko(tournament, playerIDs, current, rounds)
+	} else {
+		ratedGames := make(map[int]int)
+		for _, playerID := range playerIDs {
+			_, ratedGames[playerID], err = GetAccountRating(conn, playerID)
+			if err != nil {
+				log.Println(err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the rating list"})
+				return
+			}
+		}
+
+		eloChanges = CalculateElo(tournament, playerIDs, ratings, ratedGames, rounds)
+	}
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to start a transaction"})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	_, err = tx.Exec(context.Background(), "update tournaments set status = $1, updated_at = current_timestamp where id = $2",
+		StatusFinished, tournamentID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to finish the tournament"})
+		return
+	}
+
+	if tournament.RatingSystem == RatingGlicko2 {
+		err = saveGlicko(tx, tournamentID, glickoChanges, glickoRatings)
+	} else {
+		err = saveElo(tx, tournamentID, eloChanges)
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to save the rating changes"})
+		return
+	}
+
+	if err = tx.Commit(context.Background()); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to finish the tournament"})
+		return
+	}
+
+	if tournament.RatingSystem == RatingGlicko2 {
+		c.JSON(http.StatusOK, gin.H{"changes": glickoChanges})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"changes": eloChanges})
+}
+
+// getEloChanges returns the stored Elo rating changes, either of a tournament or of a user
+func getEloChanges(conn *pgx.Conn, column string, id int) ([]RatingChange, error) {
+	rows, err := conn.Query(context.Background(), "select c.tournament_id, c.user_id, c.rating_before, c.games, c.score, c.expected, "+
+		"c.change, c.rating_after from rating_changes c join tournaments t on t.id = c.tournament_id where c."+column+" = $1 "+
+		"order by t.updated_at, c.change desc", id)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]RatingChange, 0)
+	for rows.Next() {
+		change := RatingChange{}
+		err = rows.Scan(&change.TournamentID, &change.PlayerID, &change.RatingBefore, &change.Games, &change.Score, &change.Expected,
+			&change.Change, &change.RatingAfter)
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, change)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return changes, nil
+}
+
+// getGlickoChanges returns the stored Glicko-2 rating changes, either of a tournament or of a user
+func getGlickoChanges(conn *pgx.Conn, column string, id int) ([]GlickoChange, error) {
+	rows, err := conn.Query(context.Background(), "select h.tournament_id, h.user_id, h.games, h.score, h.rating_before, h.deviation_before, "+
+		"h.rating, h.deviation, h.volatility from glicko_history h join tournaments t on t.id = h.tournament_id where h."+column+" = $1 "+
+		"order by t.updated_at, h.rating desc", id)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]GlickoChange, 0)
+	for rows.Next() {
+		change := GlickoChange{}
+		err = rows.Scan(&change.TournamentID, &change.PlayerID, &change.Games, &change.Score, &change.Before.Rating, &change.Before.Deviation,
+			&change.After.Rating, &change.After.Deviation, &change.After.Volatility)
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, change)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return changes, nil
+}
+
+// GetRatingReport returns the rating changes of the players of a finished tournament
+func GetRatingReport(c *gin.Context) {
+	tournamentID, err := strconv.Atoi(c.Param("tournamentID"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if err = CreateRatingTables(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the tables for the ratings"})
+		return
+	}
+
+	tournament := Tournament{ID: tournamentID}
+	if err = tournament.GetTournament(conn); err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a tournament with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the information about the tournament"})
+		return
+	}
+
+	if tournament.RatingSystem == RatingGlicko2 {
+		changes, err := getGlickoChanges(conn, "tournament_id", tournamentID)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the rating changes"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"rating_system": tournament.RatingSystem, "changes": changes})
+		return
+	}
+
+	changes, err := getEloChanges(conn, "tournament_id", tournamentID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the rating changes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rating_system": tournament.RatingSystem, "changes": changes})
+}
+
+// GetRatingHistory returns the rating changes of a user in every rated tournament, oldest first
+func GetRatingHistory(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("userID"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the user"})
+		return
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if err = CreateRatingTables(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the tables for the ratings"})
+		return
+	}
+
+	elo, err := getEloChanges(conn, "user_id", userID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the Elo rating history"})
+		return
+	}
+
+	glicko, err := getGlickoChanges(conn, "user_id", userID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the Glicko-2 rating history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"elo": elo, "glicko2": glicko})
+}
+
+// GetLeaderboard returns the rating list of the given rating system (elo by default), the highest rating first
+func GetLeaderboard(c *gin.Context) {
+	ratingSystem := RatingElo
+	if system := c.Query("system"); system != "" {
+		var ok bool
+		ratingSystem, ok = ParseRatingSystem(system)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error invalid rating system"})
+			return
+		}
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if err = CreateRatingTables(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the tables for the ratings"})
+		return
+	}
+
+	query := "select r.user_id, a.name, r.rating::double precision, 0::double precision from ratings r join authentication a on a.id = r.user_id order by r.rating desc, r.user_id"
+	if ratingSystem == RatingGlicko2 {
+		query = "select r.user_id, a.name, r.rating, r.deviation from glicko_ratings r join authentication a on a.id = r.user_id " +
+			"order by r.rating desc, r.user_id"
+	}
+
+	rows, err := conn.Query(context.Background(), query)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the rating list"})
+		return
+	}
+
+	leaderboard := make([]LeaderboardEntry, 0)
+	for rows.Next() {
+		entry := LeaderboardEntry{Rank: len(leaderboard) + 1}
+		err = rows.Scan(&entry.PlayerID, &entry.Name, &entry.Rating, &entry.Deviation)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to read the rating list"})
+			return
+		}
+
+		leaderboard = append(leaderboard, entry)
+	}
+
+	if rows.Err() != nil {
+		log.Println(rows.Err())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to read the rating list"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rating_system": ratingSystem, "leaderboard": leaderboard})
+}
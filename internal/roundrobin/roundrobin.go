@@ -0,0 +1,100 @@
+package roundrobin
+
+import . "github.comPhantomvv1/SwissPairAPI/internal/swiss"
+
+// seatCount returns the number of seats of the Berger tables, an odd number of players gets an extra seat for the bye
+func seatCount(players int) int {
+	if players%2 != 0 {
+		return players + 1
+	}
+
+	return players
+}
+
+// Rounds returns the number of rounds of a round-robin for the given number of players
+func Rounds(players int, double bool) int {
+	if players < 2 {
+		return 0
+	}
+
+	rounds := seatCount(players) - 1
+	if double {
+		rounds *= 2
+	}
+
+	return rounds
+}
+
+// bergerRound returns the pairs of seats (white, black) in the given round of the Berger tables, seats start from 1.
+// Seats i and j below the last seat meet in the round where i + j = round + 1 (mod n - 1) and the last seat meets
+// the seat i for which 2i = round + 1 (mod n - 1)
+func bergerRound(n, round int) [][2]int {
+	mod := n - 1
+	congruent := func(value int) bool {
+		return (value-(round+1))%mod == 0
+	}
+
+	pairs := make([][2]int, 0, n/2)
+	for i := 1; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if !congruent(i + j) {
+				continue
+			}
+
+			if (i+j)%2 != 0 {
+				pairs = append(pairs, [2]int{i, j})
+			} else {
+				pairs = append(pairs, [2]int{j, i})
+			}
+		}
+
+		if congruent(2 * i) {
+			if round%2 != 0 {
+				pairs = append(pairs, [2]int{i, n})
+			} else {
+				pairs = append(pairs, [2]int{n, i})
+			}
+		}
+	}
+
+	return pairs
+}
+
+// CreateRound returns the games of a round of the round-robin between the players, who take the seats
+// of the Berger tables in the given order. In a double round-robin the second cycle repeats the first
+// one with reversed colours. The player who would meet the extra seat of an odd field gets the bye
+func CreateRound(players []int64, round int, double bool) (pairings []Pairing, emptyPlayer int64) {
+	n := seatCount(len(players))
+	cycle := n - 1
+	reversed := false
+	if double && round > cycle {
+		round -= cycle
+		reversed = true
+	}
+
+	seat := func(i int) int64 {
+		if i > len(players) {
+			return 0
+		}
+
+		return players[i-1]
+	}
+
+	for _, pair := range bergerRound(n, round) {
+		white, black := seat(pair[0]), seat(pair[1])
+		if reversed {
+			white, black = black, white
+		}
+
+		switch {
+		case white == 0:
+			emptyPlayer = black
+		case black == 0:
+			emptyPlayer = white
+		default:
+			pairings = append(pairings, Pairing{White: white, Black: black})
+		}
+	}
+
+	return
+}
@@ -0,0 +1,55 @@
+package rounds
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	. "github.comPhantomvv1/SwissPairAPI/internal/swiss"
+)
+
+func CreateAccelerationTable(conn *pgx.Conn) error {
+	_, err := conn.Exec(context.Background(), "create table if not exists acceleration_groups (tournament_id int references tournaments(id), "+
+		"user_id int references authentication(id), unique (tournament_id, user_id))")
+	return err
+}
+
+// getBakuGroup returns group A of the accelerated rounds of the tournament, which is fixed from the ranking of the players when
+// the first round is paired so that late entries and withdrawals don't move players between the groups. It's true when the group
+// wasn't stored yet and has to be saved with the round. The ids are the active players in the order of their rating
+func getBakuGroup(conn *pgx.Conn, tournamentID int, ids []int) (map[int64]struct{}, bool, error) {
+	if err := CreateAccelerationTable(conn); err != nil {
+		return nil, false, err
+	}
+
+	stored, err := queryPlayerIDs(conn, "select user_id from acceleration_groups where tournament_id = $1", tournamentID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	groupA := make(map[int64]struct{})
+	if len(stored) > 0 {
+		for _, id := range stored {
+			groupA[int64(id)] = struct{}{}
+		}
+		return groupA, false, nil
+	}
+
+	for _, id := range ids[:min(BakuGroupSize(len(ids)), len(ids))] {
+		groupA[int64(id)] = struct{}{}
+	}
+
+	return groupA, true, nil
+}
+
+// saveBakuGroup stores group A of the accelerated rounds of the tournament
+func saveBakuGroup(tx pgx.Tx, tournamentID int, groupA map[int64]struct{}) error {
+	for id := range groupA {
+		_, err := tx.Exec(context.Background(), "insert into acceleration_groups (tournament_id, user_id) values ($1, $2) on conflict do nothing",
+			tournamentID, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package rounds
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	. "github.comPhantomvv1/SwissPairAPI/internal/auth"
+	. "github.comPhantomvv1/SwissPairAPI/internal/tournament"
+)
+
+func CreateRequestedByesTable(conn *pgx.Conn) error {
+	_, err := conn.Exec(context.Background(), "create table if not exists requested_byes (tournament_id int references tournaments(id), "+
+		"user_id int references authentication(id), round int, unique (tournament_id, user_id, round))")
+
+	return err
+}
+
+// getRequestedByes returns the ids of the players who asked for a half-point bye in the given round
+func getRequestedByes(conn *pgx.Conn, tournamentID, round int) (map[int]struct{}, error) {
+	if err := CreateRequestedByesTable(conn); err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Query(context.Background(), "select user_id from requested_byes where tournament_id = $1 and round = $2",
+		tournamentID, round)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[int]struct{})
+	for rows.Next() {
+		id := 0
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		ids[id] = struct{}{}
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return ids, nil
+}
+
+// RequestBye lets a player (or the owner of the tournament on their behalf) ask for a half-point bye in a future round
+func RequestBye(c *gin.Context) {
+	var information map[string]any
+	json.NewDecoder(c.Request.Body).Decode(&information) // token && tournamentID && round && (userID)
+
+	token, ok := information["token"].(string)
+	if !ok {
+		log.Println("Incorrectly provided token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided token"})
+		return
+	}
+
+	id, accType, err := ValidateJWT(token)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Error invalid token"})
+		return
+	}
+
+	tournamentIDFl, ok := information["tournamentID"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided id of the tournament")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return
+	}
+	tournamentID := int(tournamentIDFl)
+
+	roundFl, ok := information["round"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided round")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided round"})
+		return
+	}
+	round := int(roundFl)
+
+	userID := id
+	if userIDFl, ok := information["userID"].(float64); ok {
+		userID = int(userIDFl)
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if err = CreateRoundsTable(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the table for the rounds"})
+		return
+	}
+
+	if err = CreateRequestedByesTable(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the table for the requested byes"})
+		return
+	}
+
+	tournament := Tournament{ID: tournamentID}
+	if err = tournament.GetTournament(conn); err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a tournament with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the owner of the tournament"})
+		return
+	}
+
+	if userID != id && accType != Admin && id != tournament.OwnerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Error only admins and the owner of the tournament can request byes for other players"})
+		return
+	}
+
+	if tournament.IsOver() {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrTournamentOver.Error()})
+		return
+	}
+
+	// Only the Swiss rounds of individual tournaments give half-point byes, the other formats have fixed schedules
+	swissRound := tournament.Format == FormatSwiss || (tournament.Format == FormatSwissKnockout && round <= tournament.SwissRounds)
+	if !swissRound || tournament.TeamBoards != 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error byes can only be requested for the Swiss rounds of individual tournaments"})
+		return
+	}
+
+	withdrawn := false
+	err = conn.QueryRow(context.Background(), "select withdrawn from players where tournament_id = $1 and user_id = $2",
+		tournamentID, userID).Scan(&withdrawn)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error the user doesn't play in this tournament"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to check the players of the tournament"})
+		return
+	}
+
+	if withdrawn {
+		c.JSON(http.StatusConflict, gin.H{"error": "Error the player has withdrawn from the tournament"})
+		return
+	}
+
+	lastRound, err := GetLastRoundNumber(conn, tournamentID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the current round of the tournament"})
+		return
+	}
+
+	if round <= lastRound {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error byes can only be requested for future rounds"})
+		return
+	}
+
+	_, err = conn.Exec(context.Background(), "insert into requested_byes (tournament_id, user_id, round) values ($1, $2, $3) "+
+		"on conflict do nothing", tournamentID, userID, round)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to save the requested bye"})
+		return
+	}
+
+	c.JSON(http.StatusOK, nil)
+}
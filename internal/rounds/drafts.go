@@ -0,0 +1,568 @@
+package rounds
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	. "github.comPhantomvv1/SwissPairAPI/internal/auth"
+	. "github.comPhantomvv1/SwissPairAPI/internal/tournament"
+)
+
+const (
+	ChangeSwap    = "swap"    // Two players exchange their places, a player moved to the bye gets it instead of the other one
+	ChangeColor   = "color"   // The players of a game exchange their colours
+	ChangeBye     = "bye"     // The pairing-allocated bye goes to another player
+	ChangePublish = "publish" // The round is published and its pairings can't be changed anymore
+	ChangeDelete  = "delete"  // The round is deleted together with its games
+)
+
+var (
+	ErrNoDraft        = errors.New("Error the tournament doesn't have a draft round")
+	ErrDraftPending   = errors.New("Error the tournament already has a draft round")
+	ErrNotSwissRound  = errors.New("Error only the rounds paired with the Swiss system can be proposed as drafts")
+	ErrPlayerNotFound = errors.New("Error the player doesn't play in the round")
+	ErrRequestedBye   = errors.New("Error the players who requested a bye can't be moved")
+	ErrSameGame       = errors.New("Error the players already play each other")
+	ErrNoByeInRound   = errors.New("Error the round doesn't have a pairing-allocated bye")
+	ErrHasBye         = errors.New("Error the player already has the bye")
+)
+
+// PairingChange is a manual change of the pairings of a round, kept so that disputes can be resolved
+type PairingChange struct {
+	ID        int       `json:"id"`
+	Round     int       `json:"round"`
+	ChangedBy int       `json:"changed_by"` // 0 for the rounds published by the schedule
+	Action    string    `json:"action"`
+	Player1ID int       `json:"player_1_id,omitempty"`
+	Player2ID int       `json:"player_2_id,omitempty"`
+	Board     int       `json:"board,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateRoundStatesTable creates the table of the rounds proposed as drafts. Rounds without a state were published when they were created
+func CreateRoundStatesTable(conn *pgx.Conn) error {
+	_, err := conn.Exec(context.Background(), "create table if not exists round_states (tournament_id int references tournaments(id), "+
+		"round int, published boolean default false, published_by int references authentication(id), published_at timestamp, "+
+		"unique (tournament_id, round))")
+
+	return err
+}
+
+func CreatePairingChangesTable(conn *pgx.Conn) error {
+	_, err := conn.Exec(context.Background(), "create table if not exists pairing_changes (id serial primary key, "+
+		"tournament_id int references tournaments(id), round int, changed_by int references authentication(id), action text, "+
+		"pl_1 int, pl_2 int, board int, note text, created_at timestamp)")
+
+	return err
+}
+
+// GetDraftRound returns the number of the round of the tournament that isn't published yet or 0 when there isn't one
+func GetDraftRound(conn *pgx.Conn, tournamentID int) (int, error) {
+	if err := CreateRoundStatesTable(conn); err != nil {
+		return 0, err
+	}
+
+	round := 0
+	err := conn.QueryRow(context.Background(), "select round from round_states where tournament_id = $1 and not published",
+		tournamentID).Scan(&round)
+	if err != nil && err != pgx.ErrNoRows {
+		return 0, err
+	}
+
+	return round, nil
+}
+
+// IsSwissRound reports whether the next round of the tournament pairs individual players with the Swiss system
+func IsSwissRound(conn *pgx.Conn, tournament Tournament) (bool, error) {
+	if tournament.TeamBoards != 0 {
+		return false, nil
+	}
+
+	switch tournament.Format {
+	case FormatSwiss:
+		return true, nil
+	case FormatSwissKnockout:
+		lastRound, err := GetLastRoundNumber(conn, tournament.ID)
+		if err != nil {
+			return false, err
+		}
+
+		return lastRound < tournament.SwissRounds, nil
+	}
+
+	return false, nil
+}
+
+// getRoundGames returns the games of the given round of the tournament
+func getRoundGames(conn *pgx.Conn, tournamentID, round int) ([]Round, error) {
+	rounds, _, err := GetRounds(conn, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	games := make([]Round, 0)
+	for _, game := range rounds {
+		if game.Round == round {
+			games = append(games, game)
+		}
+	}
+
+	return games, nil
+}
+
+// swapPlayers exchanges the places of two players in the games of a round and returns the games that changed.
+// Every player takes the colour of the place they move to
+func swapPlayers(games []Round, player1, player2 int) ([]Round, error) {
+	index1, index2 := -1, -1
+	for i, game := range games {
+		if game.Player1ID == player1 || game.Player2ID == player1 {
+			index1 = i
+		}
+		if game.Player1ID == player2 || game.Player2ID == player2 {
+			index2 = i
+		}
+	}
+
+	if index1 == -1 || index2 == -1 {
+		return nil, ErrPlayerNotFound
+	}
+
+	if games[index1].Result == ResultRequestedBye || games[index2].Result == ResultRequestedBye {
+		return nil, ErrRequestedBye
+	}
+
+	if index1 == index2 {
+		return nil, ErrSameGame
+	}
+
+	replace := func(game Round, old, new int) Round {
+		if game.Player1ID == old {
+			game.Player1ID = new
+		} else {
+			game.Player2ID = new
+		}
+
+		return game
+	}
+
+	return []Round{replace(games[index1], player1, player2), replace(games[index2], player2, player1)}, nil
+}
+
+// saveChange stores a manual change of the pairings in the audit trail of the round
+func saveChange(tx pgx.Tx, tournamentID int, change PairingChange) error {
+	_, err := tx.Exec(context.Background(), "insert into pairing_changes (tournament_id, round, changed_by, action, pl_1, pl_2, board, "+
+		"note, created_at) values ($1, $2, $3, $4, $5, $6, $7, $8, current_timestamp)", tournamentID, change.Round, nullable(change.ChangedBy),
+		change.Action, nullable(change.Player1ID), nullable(change.Player2ID), nullable(change.Board), change.Note)
+
+	return err
+}
+
+// draftTournament validates the token and returns the tournament when the user can edit its pairings
+func draftTournament(c *gin.Context, conn *pgx.Conn, information map[string]any) (int, Tournament, bool) {
+	token, ok := information["token"].(string)
+	if !ok {
+		log.Println("Incorrectly provided token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided token"})
+		return 0, Tournament{}, false
+	}
+
+	id, accType, err := ValidateJWT(token)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Error invalid token"})
+		return 0, Tournament{}, false
+	}
+
+	tournamentIDFl, ok := information["tournamentID"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided id of the tournament")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return 0, Tournament{}, false
+	}
+
+	for _, create := range []func(*pgx.Conn) error{CreateRoundsTable, CreateRoundStatesTable, CreatePairingChangesTable} {
+		if err = create(conn); err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the tables for the rounds"})
+			return 0, Tournament{}, false
+		}
+	}
+
+	tournament := Tournament{ID: int(tournamentIDFl)}
+	if err = tournament.GetTournament(conn); err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a tournament with this id doesn't exist"})
+			return 0, Tournament{}, false
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the information about the tournament"})
+		return 0, Tournament{}, false
+	}
+
+	if accType != Admin && id != tournament.OwnerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Error only admins and the owner of the tournament can change the pairings"})
+		return 0, Tournament{}, false
+	}
+
+	if tournament.IsOver() {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrTournamentOver.Error()})
+		return 0, Tournament{}, false
+	}
+
+	if !tournament.CanPair() {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrCannotPair.Error()})
+		return 0, Tournament{}, false
+	}
+
+	return id, tournament, true
+}
+
+// ProposeRound pairs the next Swiss round as a draft, which the owner can edit before publishing it
+func ProposeRound(c *gin.Context) {
+	var information map[string]any
+	json.NewDecoder(c.Request.Body).Decode(&information) // token && tournamentID
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	_, tournament, ok := draftTournament(c, conn, information)
+	if !ok {
+		return
+	}
+
+	draft, err := GetDraftRound(conn, tournament.ID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the draft round"})
+		return
+	}
+
+	if draft != 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrDraftPending.Error()})
+		return
+	}
+
+	swissRound, err := IsSwissRound(conn, tournament)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the current round of the tournament"})
+		return
+	}
+
+	if !swissRound {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrNotSwissRound.Error()})
+		return
+	}
+
+	roundNumber, rounds, err := createSwissRound(conn, tournament, true)
+	if err != nil {
+		switch err {
+		case ErrRoundNotFinished:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case ErrNoPairing:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the draft round"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"round": roundNumber, "games": rounds})
+}
+
+// EditPairing changes the pairings of the draft round: two players can swap places, the players of a board
+// can swap colours and the bye can go to another player. Every change is kept in the audit trail of the round
+func EditPairing(c *gin.Context) {
+	var information map[string]any
+	json.NewDecoder(c.Request.Body).Decode(&information) // token && tournamentID && action && ((player1 && player2) || board || player1) && (note)
+
+	action, ok := information["action"].(string)
+	if !ok || (action != ChangeSwap && action != ChangeColor && action != ChangeBye) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided change of the pairings"})
+		return
+	}
+
+	player1Fl, _ := information["player1"].(float64)
+	player2Fl, _ := information["player2"].(float64)
+	boardFl, _ := information["board"].(float64)
+	note, _ := information["note"].(string)
+	change := PairingChange{Action: action, Player1ID: int(player1Fl), Player2ID: int(player2Fl), Board: int(boardFl), Note: note}
+
+	if (action == ChangeSwap && (change.Player1ID == 0 || change.Player2ID == 0)) || (action == ChangeBye && change.Player1ID == 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided players"})
+		return
+	}
+
+	if action == ChangeColor && change.Board == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided board"})
+		return
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	id, tournament, ok := draftTournament(c, conn, information)
+	if !ok {
+		return
+	}
+
+	draft, err := GetDraftRound(conn, tournament.ID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the draft round"})
+		return
+	}
+
+	if draft == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrNoDraft.Error()})
+		return
+	}
+	change.Round, change.ChangedBy = draft, id
+
+	games, err := getRoundGames(conn, tournament.ID, draft)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the games of the draft round"})
+		return
+	}
+
+	var changed []Round
+	switch action {
+	case ChangeSwap:
+		changed, err = swapPlayers(games, change.Player1ID, change.Player2ID)
+	case ChangeBye:
+		err = ErrNoByeInRound
+		for _, game := range games {
+			if game.Result == ResultBye {
+				if game.Player1ID == change.Player1ID {
+					err = ErrHasBye
+					break
+				}
+
+				change.Player2ID = game.Player1ID
+				changed, err = swapPlayers(games, change.Player1ID, game.Player1ID)
+				break
+			}
+		}
+	case ChangeColor:
+		err = ErrPlayerNotFound
+		for _, game := range games {
+			if game.Board == change.Board && game.Player2ID != 0 {
+				game.Player1Color, game.Player2Color = game.Player2Color, game.Player1Color
+				changed, err = []Round{game}, nil
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		if err == ErrPlayerNotFound && action == ChangeColor {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error the round doesn't have a game on this board"})
+			return
+		}
+
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to start a transaction"})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	for _, game := range changed {
+		_, err = tx.Exec(context.Background(), "update rounds set pl_1 = $1, pl_2 = $2, pl_1_color = $3 where id = $4", game.Player1ID,
+			nullable(game.Player2ID), nullable(game.Player1Color), game.ID)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to save the pairings"})
+			return
+		}
+	}
+
+	if err = saveChange(tx, tournament.ID, change); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to save the change of the pairings"})
+		return
+	}
+
+	if err = tx.Commit(context.Background()); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to save the pairings"})
+		return
+	}
+
+	games, err = getRoundGames(conn, tournament.ID, draft)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the games of the draft round"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"round": draft, "games": games})
+}
+
+// PublishRound publishes the draft round, which locks its pairings and opens it for results
+func PublishRound(c *gin.Context) {
+	var information map[string]any
+	json.NewDecoder(c.Request.Body).Decode(&information) // token && tournamentID && (note)
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	id, tournament, ok := draftTournament(c, conn, information)
+	if !ok {
+		return
+	}
+
+	draft, err := GetDraftRound(conn, tournament.ID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the draft round"})
+		return
+	}
+
+	if draft == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrNoDraft.Error()})
+		return
+	}
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to start a transaction"})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	_, err = tx.Exec(context.Background(), "update round_states set published = true, published_by = $1, published_at = current_timestamp "+
+		"where tournament_id = $2 and round = $3", id, tournament.ID, draft)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to publish the round"})
+		return
+	}
+
+	note, _ := information["note"].(string)
+	if err = saveChange(tx, tournament.ID, PairingChange{Round: draft, ChangedBy: id, Action: ChangePublish, Note: note}); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to save the change of the pairings"})
+		return
+	}
+
+	if err = tx.Commit(context.Background()); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to publish the round"})
+		return
+	}
+
+	c.JSON(http.StatusOK, nil)
+}
+
+// GetPairingChanges returns the audit trail of the manual changes of the pairings, optionally only for one round (?round=)
+func GetPairingChanges(c *gin.Context) {
+	tournamentID, err := strconv.Atoi(c.Param("tournamentID"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return
+	}
+
+	round := 0
+	if value := c.Query("round"); value != "" {
+		round, err = strconv.Atoi(value)
+		if err != nil || round < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided round"})
+			return
+		}
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if err = CreatePairingChangesTable(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the table for the changes of the pairings"})
+		return
+	}
+
+	rows, err := conn.Query(context.Background(), "select id, round, changed_by, action, pl_1, pl_2, board, note, created_at "+
+		"from pairing_changes where tournament_id = $1 and ($2 = 0 or round = $2) order by id", tournamentID, round)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the changes of the pairings"})
+		return
+	}
+
+	changes := make([]PairingChange, 0)
+	for rows.Next() {
+		var change PairingChange
+		var changedBy, pl1, pl2, board *int
+		var note *string
+		err = rows.Scan(&change.ID, &change.Round, &changedBy, &change.Action, &pl1, &pl2, &board, &note, &change.CreatedAt)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the changes of the pairings"})
+			return
+		}
+
+		if changedBy != nil {
+			change.ChangedBy = *changedBy
+		}
+		if pl1 != nil {
+			change.Player1ID = *pl1
+		}
+		if pl2 != nil {
+			change.Player2ID = *pl2
+		}
+		if board != nil {
+			change.Board = *board
+		}
+		if note != nil {
+			change.Note = *note
+		}
+		changes = append(changes, change)
+	}
+
+	if rows.Err() != nil {
+		log.Println(rows.Err())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the changes of the pairings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"changes": changes})
+}
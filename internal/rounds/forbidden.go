@@ -0,0 +1,325 @@
+package rounds
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	. "github.comPhantomvv1/SwissPairAPI/internal/auth"
+	. "github.comPhantomvv1/SwissPairAPI/internal/tournament"
+)
+
+// ForbiddenPairing is a group of players who shouldn't meet, like teammates, relatives or players of the same club
+type ForbiddenPairing struct {
+	ID           int    `json:"id"`
+	TournamentID int    `json:"tournament_id"`
+	Players      []int  `json:"players"`
+	Reason       string `json:"reason"`
+	UntilRound   int    `json:"until_round"` // Last round the players are kept apart, 0 for the whole tournament
+}
+
+func CreateForbiddenPairingsTable(conn *pgx.Conn) error {
+	_, err := conn.Exec(context.Background(), "create table if not exists forbidden_pairings (id serial primary key, "+
+		"tournament_id int references tournaments(id), players int[], reason text, until_round int default 0)")
+
+	return err
+}
+
+// GetForbiddenPairings returns the groups of players who shouldn't meet in the tournament
+func GetForbiddenPairings(conn *pgx.Conn, tournamentID int) ([]ForbiddenPairing, error) {
+	if err := CreateForbiddenPairingsTable(conn); err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Query(context.Background(), "select id, players, reason, until_round from forbidden_pairings "+
+		"where tournament_id = $1 order by id", tournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	forbidden := make([]ForbiddenPairing, 0)
+	for rows.Next() {
+		group := ForbiddenPairing{TournamentID: tournamentID}
+		err = rows.Scan(&group.ID, &group.Players, &group.Reason, &group.UntilRound)
+		if err != nil {
+			return nil, err
+		}
+
+		forbidden = append(forbidden, group)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return forbidden, nil
+}
+
+// getForbiddenOpponents returns for every player the players they shouldn't meet in the given round
+func getForbiddenOpponents(conn *pgx.Conn, tournamentID, round int) (map[int]map[int64]struct{}, error) {
+	forbidden, err := GetForbiddenPairings(conn, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	opponents := make(map[int]map[int64]struct{})
+	for _, group := range forbidden {
+		if group.UntilRound != 0 && round > group.UntilRound {
+			continue
+		}
+
+		for _, id := range group.Players {
+			for _, other := range group.Players {
+				if other == id {
+					continue
+				}
+
+				if _, has := opponents[id]; !has {
+					opponents[id] = make(map[int64]struct{})
+				}
+				opponents[id][int64(other)] = struct{}{}
+			}
+		}
+	}
+
+	return opponents, nil
+}
+
+// AddForbiddenPairing lets the owner of the tournament declare a group of players who shouldn't meet
+func AddForbiddenPairing(c *gin.Context) {
+	var information map[string]any
+	json.NewDecoder(c.Request.Body).Decode(&information) // token && tournamentID && players && (reason) && (untilRound)
+
+	token, ok := information["token"].(string)
+	if !ok {
+		log.Println("Incorrectly provided token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided token"})
+		return
+	}
+
+	id, accType, err := ValidateJWT(token)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Error invalid token"})
+		return
+	}
+
+	tournamentIDFl, ok := information["tournamentID"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided id of the tournament")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return
+	}
+	tournamentID := int(tournamentIDFl)
+
+	playersAny, ok := information["players"].([]any)
+	if !ok {
+		log.Println("Incorrectly provided players")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided players"})
+		return
+	}
+
+	players := make([]int, 0, len(playersAny))
+	seen := make(map[int]struct{})
+	for _, player := range playersAny {
+		playerFl, ok := player.(float64)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided players"})
+			return
+		}
+
+		if _, has := seen[int(playerFl)]; has {
+			continue
+		}
+		seen[int(playerFl)] = struct{}{}
+		players = append(players, int(playerFl))
+	}
+
+	if len(players) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error a forbidden pairing needs at least two players"})
+		return
+	}
+
+	reason, _ := information["reason"].(string)
+
+	untilRound := 0
+	if untilRoundFl, ok := information["untilRound"].(float64); ok {
+		untilRound = int(untilRoundFl)
+		if untilRound < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided last round of the forbidden pairing"})
+			return
+		}
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if err = CreateForbiddenPairingsTable(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the table for the forbidden pairings"})
+		return
+	}
+
+	tournament := Tournament{ID: tournamentID}
+	if err = tournament.GetTournament(conn); err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a tournament with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the owner of the tournament"})
+		return
+	}
+
+	if accType != Admin && id != tournament.OwnerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Error only admins and the owner of the tournament can declare forbidden pairings"})
+		return
+	}
+
+	if tournament.IsOver() {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrTournamentOver.Error()})
+		return
+	}
+
+	registered := 0
+	err = conn.QueryRow(context.Background(), "select count(*) from players where tournament_id = $1 and user_id = any($2)",
+		tournamentID, players).Scan(&registered)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to check the players of the tournament"})
+		return
+	}
+
+	if registered != len(players) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Error not all the users play in this tournament"})
+		return
+	}
+
+	group := ForbiddenPairing{TournamentID: tournamentID, Players: players, Reason: reason, UntilRound: untilRound}
+	err = conn.QueryRow(context.Background(), "insert into forbidden_pairings (tournament_id, players, reason, until_round) "+
+		"values ($1, $2, $3, $4) returning id", tournamentID, players, reason, untilRound).Scan(&group.ID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to save the forbidden pairing"})
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+func GetTournamentForbiddenPairings(c *gin.Context) {
+	tournamentID, err := strconv.Atoi(c.Param("tournamentID"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	forbidden, err := GetForbiddenPairings(conn, tournamentID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the forbidden pairings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, forbidden)
+}
+
+func RemoveForbiddenPairing(c *gin.Context) {
+	var information map[string]any
+	json.NewDecoder(c.Request.Body).Decode(&information) // token && id
+
+	token, ok := information["token"].(string)
+	if !ok {
+		log.Println("Incorrectly provided token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided token"})
+		return
+	}
+
+	id, accType, err := ValidateJWT(token)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Error invalid token"})
+		return
+	}
+
+	forbiddenIDFl, ok := information["id"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided id of the forbidden pairing")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the forbidden pairing"})
+		return
+	}
+	forbiddenID := int(forbiddenIDFl)
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if err = CreateForbiddenPairingsTable(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the table for the forbidden pairings"})
+		return
+	}
+
+	tournamentID := 0
+	err = conn.QueryRow(context.Background(), "select tournament_id from forbidden_pairings where id = $1", forbiddenID).Scan(&tournamentID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a forbidden pairing with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the forbidden pairing"})
+		return
+	}
+
+	tournament := Tournament{ID: tournamentID}
+	if err = tournament.GetTournament(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the owner of the tournament"})
+		return
+	}
+
+	if accType != Admin && id != tournament.OwnerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Error only admins and the owner of the tournament can remove forbidden pairings"})
+		return
+	}
+
+	if tournament.IsOver() {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrTournamentOver.Error()})
+		return
+	}
+
+	_, err = conn.Exec(context.Background(), "delete from forbidden_pairings where id = $1", forbiddenID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to remove the forbidden pairing"})
+		return
+	}
+
+	c.JSON(http.StatusOK, nil)
+}
@@ -0,0 +1,267 @@
+package rounds
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.comPhantomvv1/SwissPairAPI/internal/knockout"
+	. "github.comPhantomvv1/SwissPairAPI/internal/swiss"
+	. "github.comPhantomvv1/SwissPairAPI/internal/tournament"
+)
+
+var (
+	ErrCutPending  = errors.New("Error the players for the knockout bracket haven't been chosen yet")
+	ErrNoKnockout  = errors.New("Error the tournament doesn't have a knockout bracket")
+	ErrDrawInMatch = errors.New("Error a game of the knockout bracket needs a winner")
+)
+
+// IsKnockout reports whether the tournament is played, at least in part, in a knockout bracket
+func IsKnockout(tournament Tournament) bool {
+	return tournament.Format == FormatSingleElimination || tournament.Format == FormatDoubleElimination ||
+		tournament.Format == FormatSwissKnockout
+}
+
+// IsRoundFinished reports whether all the games of the round have a result
+func IsRoundFinished(conn *pgx.Conn, tournamentID, round int) (bool, error) {
+	unfinished := 0
+	err := conn.QueryRow(context.Background(), "select count(*) from rounds where tournament_id = $1 and round = $2 and "+
+		"pl_2 is not null and result is null", tournamentID, round).Scan(&unfinished)
+
+	return unfinished == 0, err
+}
+
+// GetSeeds returns the players seeded in the knockout bracket, best seed first
+func GetSeeds(conn *pgx.Conn, tournamentID int) ([]int64, error) {
+	rows, err := conn.Query(context.Background(), "select user_id from players where tournament_id = $1 and seed is not null "+
+		"order by seed", tournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	seeds := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		seeds = append(seeds, id)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return seeds, nil
+}
+
+// SetSeeds stores the seeds of the knockout bracket in the given order, the other players aren't seeded
+func SetSeeds(tx pgx.Tx, tournamentID int, seeds []int64) error {
+	_, err := tx.Exec(context.Background(), "update players set seed = null where tournament_id = $1", tournamentID)
+	if err != nil {
+		return err
+	}
+
+	for i, id := range seeds {
+		_, err = tx.Exec(context.Background(), "update players set seed = $1 where tournament_id = $2 and user_id = $3", i+1, tournamentID, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bracketSeeds returns the seeds of the bracket. Knockout tournaments without a Swiss part that haven't
+// started yet are seeded by rating
+func bracketSeeds(conn *pgx.Conn, tournament Tournament) ([]int64, error) {
+	seeds, err := GetSeeds(conn, tournament.ID)
+	if err != nil || len(seeds) > 0 {
+		return seeds, err
+	}
+
+	if tournament.Format == FormatSwissKnockout {
+		return nil, ErrCutPending
+	}
+
+	ids, err := GetActivePlayerIDs(conn, tournament.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		seeds = append(seeds, int64(id))
+	}
+
+	return seeds, nil
+}
+
+// GetBracket builds the knockout bracket of the tournament from its seeds and advances the winners of the played games
+func GetBracket(conn *pgx.Conn, tournament Tournament) (*knockout.Bracket, []int64, error) {
+	if !IsKnockout(tournament) {
+		return nil, nil, ErrNoKnockout
+	}
+
+	seeds, err := bracketSeeds(conn, tournament)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bracket := knockout.NewBracket(seeds, tournament.Format == FormatDoubleElimination)
+
+	rounds, _, err := GetRounds(conn, tournament.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, round := range rounds {
+		if round.Match == 0 {
+			continue
+		}
+
+		if winner := round.Winner(); winner != 0 {
+			err = bracket.SetWinner(round.Match, int64(winner))
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return bracket, seeds, nil
+}
+
+// createKnockoutRound creates a game for every match of the bracket that has both of its players
+func createKnockoutRound(conn *pgx.Conn, tournament Tournament) (int, []Round, error) {
+	lastRound, err := GetLastRoundNumber(conn, tournament.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	finished, err := IsRoundFinished(conn, tournament.ID, lastRound)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if !finished {
+		return 0, nil, ErrRoundNotFinished
+	}
+
+	bracket, seeds, err := GetBracket(conn, tournament)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if len(seeds) < 2 {
+		return 0, nil, ErrNoPairing
+	}
+
+	ready := bracket.Ready()
+	if len(ready) == 0 {
+		return 0, nil, ErrScheduleComplete
+	}
+
+	_, history, err := GetRounds(conn, tournament.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	games := make([]Round, 0, len(ready))
+	for _, match := range ready {
+		players := make([]Player, 0, 2)
+		for _, id := range []int64{match.Player1, match.Player2} {
+			index := GetIndexOfPlayer(history, int(id))
+			if index == -1 {
+				players = append(players, Player{Id: id, Opponent: make(map[int64]struct{})})
+				continue
+			}
+
+			players = append(players, history[index])
+		}
+
+		pairing := AllocateColors([][]int64{{match.Player1, match.Player2}}, players)[0]
+		games = append(games, Round{Player1ID: int(pairing.White), Player2ID: int(pairing.Black), Player1Color: ColorWhite,
+			Player2Color: ColorBlack, Match: match.ID})
+	}
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	// The seeding by rating is kept once the bracket starts
+	if tournament.Format != FormatSwissKnockout && lastRound == 0 {
+		if err = SetSeeds(tx, tournament.ID, seeds); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	rounds, err := insertRound(tx, tournament, lastRound+1, games)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err = tx.Commit(context.Background()); err != nil {
+		return 0, nil, err
+	}
+
+	return lastRound + 1, rounds, nil
+}
+
+func GetTournamentBracket(c *gin.Context) {
+	tournamentID, err := strconv.Atoi(c.Param("tournamentID"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if err = CreateRoundsTable(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the table for the rounds"})
+		return
+	}
+
+	tournament := Tournament{ID: tournamentID}
+	if err = tournament.GetTournament(conn); err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a tournament with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the information about the tournament"})
+		return
+	}
+
+	bracket, seeds, err := GetBracket(conn, tournament)
+	if err != nil {
+		switch err {
+		case ErrNoKnockout:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case ErrCutPending:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the bracket"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"seeds": seeds, "bracket": bracket, "champion": bracket.Champion()})
+}
@@ -0,0 +1,485 @@
+package rounds
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	. "github.comPhantomvv1/SwissPairAPI/internal/auth"
+	. "github.comPhantomvv1/SwissPairAPI/internal/swiss"
+	. "github.comPhantomvv1/SwissPairAPI/internal/tournament"
+)
+
+var (
+	ErrGamesNeeded  = errors.New("Error the games of the match are needed for its result")
+	ErrNoMatches    = errors.New("Error the tournament isn't played in matches of several games")
+	ErrInvalidGames = errors.New("Error the games don't fit in a match of the tournament")
+)
+
+func GetRound(conn *pgx.Conn, roundID int) (Round, error) {
+	round := Round{ID: roundID}
+	var pl2, pl1Color, result, match, games1, games2, gamesDrawn *int
+	err := conn.QueryRow(context.Background(), "select round, board, pl_1, pl_2, pl_1_color, result, match, games_1, games_2, games_drawn, "+
+		"tournament_id from rounds where id = $1", roundID).Scan(&round.Round, &round.Board, &round.Player1ID, &pl2, &pl1Color, &result, &match,
+		&games1, &games2, &gamesDrawn, &round.TournamentID)
+	if err != nil {
+		return Round{}, err
+	}
+
+	if pl2 != nil {
+		round.Player2ID = *pl2
+	}
+	if pl1Color != nil {
+		round.Player1Color, round.Player2Color = *pl1Color, OppositeColor(*pl1Color)
+	}
+	if result != nil {
+		round.Result = *result
+	}
+	if match != nil {
+		round.Match = *match
+	}
+	if games1 != nil && games2 != nil && gamesDrawn != nil {
+		round.Player1Games, round.Player2Games, round.DrawnGames = *games1, *games2, *gamesDrawn
+	}
+	round.Played = round.IsPlayed()
+
+	return round, nil
+}
+
+// IsRoundOpen reports whether results can still be entered for the games of the round. The whole
+// schedule of a round-robin is created at once, so all of its rounds stay open until the end.
+// A draft round isn't open before it's published
+func IsRoundOpen(conn *pgx.Conn, tournament Tournament, round int) (bool, error) {
+	if tournament.Status != StatusInProgress {
+		return false, nil
+	}
+
+	draft, err := GetDraftRound(conn, tournament.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if draft == round {
+		return false, nil
+	}
+
+	if tournament.Format == FormatRoundRobin || tournament.Format == FormatDoubleRoundRobin {
+		return true, nil
+	}
+
+	lastRound, err := GetLastRoundNumber(conn, tournament.ID)
+	if err != nil {
+		return false, err
+	}
+
+	return round == lastRound, nil
+}
+
+// validResult reports whether the result is one the players can report for a game they played
+func validResult(result int) bool {
+	switch result {
+	case ResultPlayer1Win, ResultPlayer2Win, ResultDraw, ResultArmageddonPlayer1, ResultArmageddonPlayer2:
+		return true
+	}
+
+	return false
+}
+
+// validArbiterResult reports whether the owner of the tournament can set the result, which can also be a forfeit
+func validArbiterResult(result int) bool {
+	switch result {
+	case ResultPlayer1Forfeit, ResultPlayer2Forfeit, ResultDoubleForfeit, ResultUnplayed:
+		return true
+	}
+
+	return validResult(result)
+}
+
+// decisive reports whether the result has a winner, which every game of a knockout bracket needs
+func decisive(result int) bool {
+	switch result {
+	case ResultPlayer1Win, ResultPlayer2Win, ResultPlayer1Forfeit, ResultPlayer2Forfeit, ResultArmageddonPlayer1, ResultArmageddonPlayer2:
+		return true
+	}
+
+	return false
+}
+
+// allowedByScoring reports whether the scoring of the tournament allows the result. Draws need an armageddon
+// game in the tournaments that decide them that way and only those tournaments have armageddon results
+func allowedByScoring(tournament Tournament, result int) bool {
+	switch result {
+	case ResultDraw:
+		return tournament.Scoring.Draws == DrawsAllowed
+	case ResultArmageddonPlayer1, ResultArmageddonPlayer2:
+		return tournament.Scoring.Draws == DrawsArmageddon
+	}
+
+	return true
+}
+
+// readGames reads the games of a match, won by each player and drawn, when they're provided
+func readGames(information map[string]any) (games []int, provided, ok bool) {
+	_, has1 := information["player1Games"]
+	_, has2 := information["player2Games"]
+	if !has1 && !has2 {
+		return nil, false, true
+	}
+
+	games1, ok1 := information["player1Games"].(float64)
+	games2, ok2 := information["player2Games"].(float64)
+	drawn, _ := information["drawnGames"].(float64)
+	if !ok1 || !ok2 || games1 < 0 || games2 < 0 || drawn < 0 {
+		return nil, true, false
+	}
+
+	return []int{int(games1), int(games2), int(drawn)}, true, true
+}
+
+// matchResult returns the result of the match with the given games, the player who won more games wins it
+func matchResult(games []int) int {
+	switch {
+	case games[0] > games[1]:
+		return ResultPlayer1Win
+	case games[0] < games[1]:
+		return ResultPlayer2Win
+	}
+
+	return ResultDraw
+}
+
+// checkGames checks that the games fit in a match of the tournament. The results of played matches need their games
+func checkGames(tournament Tournament, result int, games []int) error {
+	if games == nil {
+		if tournament.BestOf > 0 && validResult(result) {
+			return ErrGamesNeeded
+		}
+
+		return nil
+	}
+
+	if tournament.BestOf == 0 {
+		return ErrNoMatches
+	}
+
+	needed, total := (tournament.BestOf+1)/2, games[0]+games[1]+games[2]
+	if games[0] > needed || games[1] > needed || total == 0 || total > tournament.BestOf {
+		return ErrInvalidGames
+	}
+
+	return nil
+}
+
+// gameColumns returns the values stored for the games of a match, null when there aren't any
+func gameColumns(games []int) (*int, *int, *int) {
+	if games == nil {
+		return nil, nil, nil
+	}
+
+	return &games[0], &games[1], &games[2]
+}
+
+// SetResult lets the owner of the tournament or an admin submit, correct or clear (result 0) the result of a game.
+// The result of a match of several games comes from its games
+func SetResult(c *gin.Context) {
+	var information map[string]any
+	json.NewDecoder(c.Request.Body).Decode(&information) // token && roundID && (result || (player1Games && player2Games && (drawnGames)))
+
+	token, ok := information["token"].(string)
+	if !ok {
+		log.Println("Incorrectly provided token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided token"})
+		return
+	}
+
+	id, accType, err := ValidateJWT(token)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Error invalid token"})
+		return
+	}
+
+	roundIDFl, ok := information["roundID"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided id of the round")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the round"})
+		return
+	}
+	roundID := int(roundIDFl)
+
+	games, hasGames, ok := readGames(information)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided games"})
+		return
+	}
+
+	var result int
+	if hasGames {
+		result = matchResult(games)
+	} else {
+		resultFl, ok := information["result"].(float64)
+		if !ok {
+			log.Println("Incorrectly provided result")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided result"})
+			return
+		}
+		result = int(resultFl)
+	}
+
+	if result != 0 && !validArbiterResult(result) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error invalid result"})
+		return
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	round, err := GetRound(conn, roundID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error there is no round with this id"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the round from the database"})
+		return
+	}
+
+	tournament := Tournament{ID: round.TournamentID}
+	if err = tournament.GetTournament(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the information about the tournament"})
+		return
+	}
+
+	if accType != Admin && id != tournament.OwnerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Error only admins and the owner of the tournament can set results"})
+		return
+	}
+
+	if round.Player2ID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error the result of a bye can't be changed"})
+		return
+	}
+
+	if round.Match != 0 && result != 0 && !decisive(result) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrDrawInMatch.Error()})
+		return
+	}
+
+	if !allowedByScoring(tournament, result) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error the scoring of the tournament doesn't allow this result"})
+		return
+	}
+
+	if err = checkGames(tournament, result, games); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	open, err := IsRoundOpen(conn, tournament, round.Round)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to check if the round is still open"})
+		return
+	}
+
+	if !open {
+		c.JSON(http.StatusConflict, gin.H{"error": "Error the round is already closed"})
+		return
+	}
+
+	var newResult *int
+	if result != 0 {
+		newResult = &result
+	}
+
+	games1, games2, gamesDrawn := gameColumns(games)
+	_, err = conn.Exec(context.Background(), "update rounds set result = $1, games_1 = $2, games_2 = $3, games_drawn = $4, "+
+		"reported_result = null, reported_by = null, reported_games = null, disputed = false where id = $5", newResult, games1, games2, gamesDrawn, roundID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to save the result"})
+		return
+	}
+
+	c.JSON(http.StatusOK, nil)
+}
+
+// ReportResult lets the players of a game report its result themselves. The result is saved only
+// after both players have reported the same result, with the same games for a match of several games.
+// When they disagree the first report is kept and the game is marked as disputed for the arbiter
+func ReportResult(c *gin.Context) {
+	var information map[string]any
+	json.NewDecoder(c.Request.Body).Decode(&information) // token && roundID && (result || (player1Games && player2Games && (drawnGames)))
+
+	token, ok := information["token"].(string)
+	if !ok {
+		log.Println("Incorrectly provided token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided token"})
+		return
+	}
+
+	id, _, err := ValidateJWT(token)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Error invalid token"})
+		return
+	}
+
+	roundIDFl, ok := information["roundID"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided id of the round")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the round"})
+		return
+	}
+	roundID := int(roundIDFl)
+
+	games, hasGames, ok := readGames(information)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided games"})
+		return
+	}
+
+	var result int
+	if hasGames {
+		result = matchResult(games)
+	} else {
+		resultFl, ok := information["result"].(float64)
+		if !ok || !validResult(int(resultFl)) {
+			log.Println("Incorrectly provided result")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided result"})
+			return
+		}
+		result = int(resultFl)
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	round, err := GetRound(conn, roundID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error there is no round with this id"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the round from the database"})
+		return
+	}
+
+	if id != round.Player1ID && id != round.Player2ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Error only the players of the game can report its result"})
+		return
+	}
+
+	if round.Match != 0 && !decisive(result) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrDrawInMatch.Error()})
+		return
+	}
+
+	tournament := Tournament{ID: round.TournamentID}
+	if err = tournament.GetTournament(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the information about the tournament"})
+		return
+	}
+
+	if !allowedByScoring(tournament, result) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error the scoring of the tournament doesn't allow this result"})
+		return
+	}
+
+	if err = checkGames(tournament, result, games); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !tournament.SelfReporting {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Error players can't report results in this tournament"})
+		return
+	}
+
+	if round.Result != 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Error the result of this game has already been set"})
+		return
+	}
+
+	open, err := IsRoundOpen(conn, tournament, round.Round)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to check if the round is still open"})
+		return
+	}
+
+	if !open {
+		c.JSON(http.StatusConflict, gin.H{"error": "Error the round is already closed"})
+		return
+	}
+
+	var reportedResult, reportedBy *int
+	var reportedGames []int
+	err = conn.QueryRow(context.Background(), "select reported_result, reported_by, reported_games from rounds where id = $1", roundID).Scan(
+		&reportedResult, &reportedBy, &reportedGames)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the reported result"})
+		return
+	}
+
+	// The opponent has already reported the same result, so it's confirmed
+	if reportedBy != nil && *reportedBy != id && *reportedResult == result && slices.Equal(reportedGames, games) {
+		games1, games2, gamesDrawn := gameColumns(games)
+		_, err = conn.Exec(context.Background(), "update rounds set result = $1, games_1 = $2, games_2 = $3, games_drawn = $4, "+
+			"reported_result = null, reported_by = null, reported_games = null, disputed = false where id = $5", result, games1, games2, gamesDrawn, roundID)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to save the result"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"confirmed": true})
+		return
+	}
+
+	// A different result from the opponent leaves their report for the arbiter and marks the game as disputed
+	if reportedBy != nil && *reportedBy != id {
+		_, err = conn.Exec(context.Background(), "update rounds set disputed = true where id = $1", roundID)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to mark the game as disputed"})
+			return
+		}
+
+		c.JSON(http.StatusConflict, gin.H{"error": "Error the result doesn't match the one reported by your opponent"})
+		return
+	}
+
+	_, err = conn.Exec(context.Background(), "update rounds set reported_result = $1, reported_by = $2, reported_games = $3 where id = $4",
+		result, id, games, roundID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to save the reported result"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"confirmed": false})
+}
@@ -3,6 +3,7 @@ package rounds
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"os"
@@ -11,75 +12,676 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5"
 	. "github.comPhantomvv1/SwissPairAPI/internal/auth"
+	"github.comPhantomvv1/SwissPairAPI/internal/roundrobin"
 	. "github.comPhantomvv1/SwissPairAPI/internal/swiss"
+	. "github.comPhantomvv1/SwissPairAPI/internal/teams"
 	. "github.comPhantomvv1/SwissPairAPI/internal/tournament"
 )
 
 type Round struct {
-	ID           int `json:"id"`
-	Player1ID    int `json:"player_1_id"`
-	Player2ID    int `json:"player_2_id"`
-	Result       int `json:"result"`
-	TournamentID int `json:"tournament_id"`
+	ID           int  `json:"id"`
+	Round        int  `json:"round"`
+	Board        int  `json:"board"`
+	Player1ID    int  `json:"player_1_id"`
+	Player2ID    int  `json:"player_2_id"`
+	Player1Color int  `json:"player_1_color"`
+	Player2Color int  `json:"player_2_color"`
+	Result       int  `json:"result"`
+	Match        int  `json:"match,omitempty"`          // Match of the knockout bracket that the game decides
+	Player1Games int  `json:"player_1_games,omitempty"` // Games player 1 won in a match of several games
+	Player2Games int  `json:"player_2_games,omitempty"` // Games player 2 won in a match of several games
+	DrawnGames   int  `json:"drawn_games,omitempty"`    // Drawn games in a match of several games
+	Played       bool `json:"played"`                   // Whether the players met over the board, see IsPlayed
+	TeamMatch    int  `json:"team_match,omitempty"`     // Match of two teams that the game belongs to
+	Disputed     bool `json:"disputed,omitempty"`       // Whether the players reported different results
+	TournamentID int  `json:"tournament_id"`
 }
 
 const (
 	ResultPlayer1Win = iota + 1
 	ResultPlayer2Win
 	ResultDraw
+	ResultBye               // Pairing-allocated bye, worth the bye value of the tournament
+	ResultRequestedBye      // Half-point bye requested by the player
+	ResultPlayer1Forfeit    // Player 1 wins because player 2 didn't show up
+	ResultPlayer2Forfeit    // Player 2 wins because player 1 didn't show up
+	ResultDoubleForfeit     // Neither of the players showed up, both lose
+	ResultUnplayed          // The game was cancelled, neither of the players scores
+	ResultArmageddonPlayer1 // The game was drawn and player 1 won the armageddon game
+	ResultArmageddonPlayer2 // The game was drawn and player 2 won the armageddon game
 )
 
+var (
+	ErrRoundNotFinished = errors.New("Error not all the results of the previous round have been entered")
+	ErrNoPairing        = errors.New("Error unable to pair the players for the next round")
+	ErrScheduleComplete = errors.New("Error all the rounds of the tournament have already been created")
+)
+
+// Points returns the points the player scored in the game with the scoring of the tournament. Byes are worth
+// a share of a win and the players who lose by forfeit don't get the points of a loss
+func (r Round) Points(playerID int, tournament Tournament) float64 {
+	scoring := tournament.Scoring
+	switch r.Result {
+	case ResultBye:
+		return tournament.ByeValue * scoring.Win
+	case ResultRequestedBye:
+		return 0.5 * scoring.Win
+	case ResultDraw:
+		return scoring.Draw
+	case ResultPlayer1Win, ResultPlayer2Win:
+		if playerID == r.Winner() {
+			return scoring.Win
+		}
+		return scoring.Loss
+	case ResultPlayer1Forfeit, ResultPlayer2Forfeit:
+		if playerID == r.Winner() {
+			return scoring.Win
+		}
+	case ResultArmageddonPlayer1, ResultArmageddonPlayer2:
+		if playerID == r.Winner() {
+			return scoring.ArmageddonWin
+		}
+		return scoring.ArmageddonLoss
+	}
+
+	return 0
+}
+
+// GameScore returns the score of the player in the game on the chess scale of 1, 0.5 and 0 whatever the scoring
+// of the tournament, which is what the ratings use. An armageddon only decides the points, the game was a draw
+func (r Round) GameScore(playerID int) float64 {
+	switch r.Result {
+	case ResultDraw, ResultArmageddonPlayer1, ResultArmageddonPlayer2:
+		return 0.5
+	case ResultPlayer1Win, ResultPlayer2Win, ResultPlayer1Forfeit, ResultPlayer2Forfeit:
+		if playerID == r.Winner() {
+			return 1.0
+		}
+	}
+
+	return 0
+}
+
+// Games returns the games the player won, lost and drew in the match. Byes and forfeits count as a match won
+// or lost without playing the other games and tournaments of single games count every game as a match of one game
+func (r Round) Games(playerID, bestOf int) (won, lost, drawn int) {
+	needed := max((bestOf+1)/2, 1)
+	switch {
+	case r.Result == ResultBye:
+		return needed, 0, 0
+	case r.Result == ResultPlayer1Forfeit || r.Result == ResultPlayer2Forfeit:
+		if playerID == r.Winner() {
+			return needed, 0, 0
+		}
+		return 0, needed, 0
+	case !r.IsPlayed() || r.Result == 0:
+		return 0, 0, 0
+	case bestOf == 0:
+		switch r.GameScore(playerID) {
+		case 1:
+			return 1, 0, 0
+		case 0.5:
+			return 0, 0, 1
+		}
+		return 0, 1, 0
+	case playerID == r.Player1ID:
+		return r.Player1Games, r.Player2Games, r.DrawnGames
+	default:
+		return r.Player2Games, r.Player1Games, r.DrawnGames
+	}
+}
+
+// Winner returns the player who won the game, including wins by forfeit and in the armageddon, or 0 when nobody did
+func (r Round) Winner() int {
+	switch r.Result {
+	case ResultPlayer1Win, ResultPlayer1Forfeit, ResultArmageddonPlayer1:
+		return r.Player1ID
+	case ResultPlayer2Win, ResultPlayer2Forfeit, ResultArmageddonPlayer2:
+		return r.Player2ID
+	}
+
+	return 0
+}
+
+// IsPlayed reports whether the players met over the board. Byes, forfeits and unplayed games don't
+// count as a meeting, so the players can still be paired against each other and keep their colours
+func (r Round) IsPlayed() bool {
+	switch r.Result {
+	case ResultBye, ResultRequestedBye, ResultPlayer1Forfeit, ResultPlayer2Forfeit, ResultDoubleForfeit, ResultUnplayed:
+		return false
+	}
+
+	return r.Player2ID != 0
+}
+
+// Opponent returns the id of the opponent of the player in the game or 0 for a bye
+func (r Round) Opponent(playerID int) int {
+	if playerID == r.Player1ID {
+		return r.Player2ID
+	}
+
+	return r.Player1ID
+}
+
+// Color returns the colour the player had in the game
+func (r Round) Color(playerID int) int {
+	if playerID == r.Player1ID {
+		return r.Player1Color
+	}
+
+	return r.Player2Color
+}
+
 func CreateRoundsTable(conn *pgx.Conn) error {
-	_, err := conn.Exec(context.Background(), "create table if not exists rounds (id serial primary key, pl_1 int "+
-		"references authentication(id), pl_2 int references authentication(id), result int check(result in (1, 2, 3)) "+
-		"tournament_id int references tournaments(id))")
+	_, err := conn.Exec(context.Background(), "create table if not exists rounds (id serial primary key, round int, board int, pl_1 int "+
+		"references authentication(id), pl_2 int references authentication(id), pl_1_color int check(pl_1_color in (1, 2)), "+
+		"result int check(result between 1 and 11), reported_result int check(reported_result in (1, 2, 3, 10, 11)), "+
+		"reported_by int references authentication(id), match int, games_1 int, games_2 int, games_drawn int, reported_games int[], "+
+		"team_match int, disputed boolean default false, tournament_id int references tournaments(id))")
 
 	return err
 }
 
+// GetRounds returns all the games of the tournament, also the ones of a draft round, with the history of every player
 func GetRounds(conn *pgx.Conn, tournamentID int) ([]Round, []Player, error) {
-	rows, err := conn.Query(context.Background(), "select id, pl_1, pl_2, result from rounds where tournament_id = $1", tournamentID)
+	return getRounds(conn, tournamentID, 0)
+}
+
+// GetPublishedRounds returns the games of the tournament without the ones of a draft round, which
+// don't count for the standings and the ratings until the round is published
+func GetPublishedRounds(conn *pgx.Conn, tournamentID int) ([]Round, []Player, error) {
+	draft, err := GetDraftRound(conn, tournamentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return getRounds(conn, tournamentID, draft)
+}
+
+// getRounds returns the games of the tournament without the ones of the skipped round, 0 skips none
+func getRounds(conn *pgx.Conn, tournamentID, skippedRound int) ([]Round, []Player, error) {
+	tournament := Tournament{ID: tournamentID}
+	if err := tournament.GetTournament(conn); err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := conn.Query(context.Background(), "select id, round, board, pl_1, pl_2, pl_1_color, result, match, games_1, games_2, "+
+		"games_drawn, team_match, disputed from rounds where tournament_id = $1 and round <> $2 order by round, board", tournamentID,
+		skippedRound)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	rounds := make([]Round, 0)
 	players := make([]Player, 0)
+	scoresBefore := make(map[int]float64) // Scores of the players before the current round
+	currentRound := 0
 	for rows.Next() {
-		id, pl1, pl2, result := 0, 0, 0, 0
-		err = rows.Scan(&id, &pl1, &pl2, &result)
+		game := Round{TournamentID: tournamentID}
+		var pl2, pl1Color, result, match, games1, games2, gamesDrawn, teamMatch *int
+		err = rows.Scan(&game.ID, &game.Round, &game.Board, &game.Player1ID, &pl2, &pl1Color, &result, &match, &games1, &games2, &gamesDrawn,
+			&teamMatch, &game.Disputed)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		rounds = append(rounds, Round{ID: id, Player1ID: pl1, Player2ID: pl2, Result: result, TournamentID: tournamentID})
+		if pl2 != nil {
+			game.Player2ID = *pl2
+		}
+		if pl1Color != nil {
+			game.Player1Color, game.Player2Color = *pl1Color, OppositeColor(*pl1Color)
+		}
+		if result != nil {
+			game.Result = *result
+		}
+		if match != nil {
+			game.Match = *match
+		}
+		if games1 != nil && games2 != nil && gamesDrawn != nil {
+			game.Player1Games, game.Player2Games, game.DrawnGames = *games1, *games2, *gamesDrawn
+		}
+		if teamMatch != nil {
+			game.TeamMatch = *teamMatch
+		}
+		game.Played = game.IsPlayed()
+		rounds = append(rounds, game)
+
+		if game.Round != currentRound {
+			for _, player := range players {
+				scoresBefore[int(player.Id)] = player.Score
+			}
+			currentRound = game.Round
+		}
 
-		index := GetIndexOfPlayer(players, pl1)
-		if index == -1 {
-			players = append(players, Player{Id: int64(pl1)})
+		index1 := getPlayer(&players, game.Player1ID)
+		if game.Player2ID == 0 {
+			players[index1].Score += game.Points(game.Player1ID, tournament)
+			if game.Result == ResultBye {
+				players[index1].HadBye = true
+				setFloat(&players[index1], game.Round, FloatDown)
+			} else {
+				setFloat(&players[index1], game.Round, FloatNone)
+			}
+			continue
 		}
-		players[index].Opponent[int64(pl2)] = struct{}{}
 
-		index = GetIndexOfPlayer(players, pl2)
-		if index == -1 {
-			players = append(players, Player{Id: int64(pl2)})
+		index2 := getPlayer(&players, game.Player2ID)
+
+		// A forfeit win is a point without playing like a bye, the players of the game haven't met
+		if !game.Played {
+			for _, index := range []int{index1, index2} {
+				id := int(players[index].Id)
+				players[index].Score += game.Points(id, tournament)
+				if game.Winner() == id {
+					players[index].HadBye = true
+					setFloat(&players[index], game.Round, FloatDown)
+				} else {
+					setFloat(&players[index], game.Round, FloatNone)
+				}
+			}
+			continue
 		}
-		players[index].Opponent[int64(pl1)] = struct{}{}
 
-		switch result {
-		case ResultPlayer1Win:
-			players[GetIndexOfPlayer(players, pl1)].Score += 1.0
-		case ResultPlayer2Win:
-			players[index].Score += 1.0
-		case ResultDraw:
-			players[GetIndexOfPlayer(players, pl1)].Score += 0.5
-			players[index].Score += 0.5
+		players[index1].Opponent[int64(game.Player2ID)] = struct{}{}
+		players[index2].Opponent[int64(game.Player1ID)] = struct{}{}
+		players[index1].Colors = append(players[index1].Colors, game.Player1Color)
+		players[index2].Colors = append(players[index2].Colors, game.Player2Color)
+
+		switch {
+		case scoresBefore[game.Player1ID] > scoresBefore[game.Player2ID]:
+			setFloat(&players[index1], game.Round, FloatDown)
+			setFloat(&players[index2], game.Round, FloatUp)
+		case scoresBefore[game.Player1ID] < scoresBefore[game.Player2ID]:
+			setFloat(&players[index1], game.Round, FloatUp)
+			setFloat(&players[index2], game.Round, FloatDown)
+		default:
+			setFloat(&players[index1], game.Round, FloatNone)
+			setFloat(&players[index2], game.Round, FloatNone)
 		}
+
+		players[index1].Score += game.Points(game.Player1ID, tournament)
+		players[index2].Score += game.Points(game.Player2ID, tournament)
+	}
+
+	if rows.Err() != nil {
+		return nil, nil, rows.Err()
+	}
+
+	for i := range players {
+		setFloat(&players[i], currentRound, FloatNone)
 	}
 
 	return rounds, players, nil
 }
 
+// getPlayer returns the index of the player with the given id, adding the player when it isn't in the list yet
+func getPlayer(players *[]Player, id int) int {
+	index := GetIndexOfPlayer(*players, id)
+	if index == -1 {
+		*players = append(*players, Player{Id: int64(id), Opponent: make(map[int64]struct{})})
+		index = len(*players) - 1
+	}
+
+	return index
+}
+
+// setFloat records the float of the player in the given round, filling the rounds the player missed
+func setFloat(player *Player, round, float int) {
+	for len(player.Floats) < round-1 {
+		player.Floats = append(player.Floats, FloatNone)
+	}
+
+	if len(player.Floats) < round {
+		player.Floats = append(player.Floats, float)
+	}
+}
+
+// GetPlayerIDs returns the ids of the users registered as players in the tournament, the highest rated first.
+// Withdrawn players are included, so that their games still count
+func GetPlayerIDs(conn *pgx.Conn, tournamentID int) ([]int, error) {
+	return queryPlayerIDs(conn, "select user_id from players where tournament_id = $1 order by rating desc, user_id", tournamentID)
+}
+
+// GetActivePlayerIDs returns the ids of the players who haven't withdrawn from the tournament, the highest rated first
+func GetActivePlayerIDs(conn *pgx.Conn, tournamentID int) ([]int, error) {
+	return queryPlayerIDs(conn, "select user_id from players where tournament_id = $1 and not withdrawn order by rating desc, user_id",
+		tournamentID)
+}
+
+// GetWithdrawnPlayers returns the ids of the players who have withdrawn from the tournament
+func GetWithdrawnPlayers(conn *pgx.Conn, tournamentID int) (map[int]struct{}, error) {
+	ids, err := queryPlayerIDs(conn, "select user_id from players where tournament_id = $1 and withdrawn", tournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	withdrawn := make(map[int]struct{})
+	for _, id := range ids {
+		withdrawn[id] = struct{}{}
+	}
+
+	return withdrawn, nil
+}
+
+func queryPlayerIDs(conn *pgx.Conn, query string, tournamentID int) ([]int, error) {
+	rows, err := conn.Query(context.Background(), query, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		id := 0
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return ids, nil
+}
+
+// GetPlayerRatings returns the rating of every player of the tournament
+func GetPlayerRatings(conn *pgx.Conn, tournamentID int) (map[int]int, error) {
+	rows, err := conn.Query(context.Background(), "select user_id, rating from players where tournament_id = $1", tournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	ratings := make(map[int]int)
+	for rows.Next() {
+		id, rating := 0, 0
+		err = rows.Scan(&id, &rating)
+		if err != nil {
+			return nil, err
+		}
+
+		ratings[id] = rating
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return ratings, nil
+}
+
+// GetLastRoundNumber returns the number of the latest round of the tournament or 0 if no round has been created yet
+func GetLastRoundNumber(conn *pgx.Conn, tournamentID int) (int, error) {
+	round := 0
+	err := conn.QueryRow(context.Background(), "select coalesce(max(round), 0) from rounds where tournament_id = $1", tournamentID).Scan(&round)
+	return round, err
+}
+
+// GetUnfinishedGames returns the number of games of the tournament that don't have a result yet
+func GetUnfinishedGames(conn *pgx.Conn, tournamentID int) (int, error) {
+	unfinished := 0
+	err := conn.QueryRow(context.Background(), "select count(*) from rounds where tournament_id = $1 and pl_2 is not null and result is null",
+		tournamentID).Scan(&unfinished)
+	return unfinished, err
+}
+
+// CreateNextRound creates the next rounds of the tournament according to its format. Swiss tournaments get their
+// next round, round-robins get their whole schedule and knockouts get the matches that are ready in the bracket.
+// It returns the number of the last created round
+func CreateNextRound(conn *pgx.Conn, tournament Tournament) (int, []Round, error) {
+	if tournament.TeamBoards != 0 {
+		return createTeamRound(conn, tournament)
+	}
+
+	switch tournament.Format {
+	case FormatRoundRobin, FormatDoubleRoundRobin:
+		return createRoundRobinRounds(conn, tournament)
+	case FormatSingleElimination, FormatDoubleElimination:
+		return createKnockoutRound(conn, tournament)
+	case FormatSwissKnockout:
+		lastRound, err := GetLastRoundNumber(conn, tournament.ID)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if lastRound >= tournament.SwissRounds {
+			return createKnockoutRound(conn, tournament)
+		}
+
+		return createSwissRound(conn, tournament, false)
+	default:
+		return createSwissRound(conn, tournament, false)
+	}
+}
+
+// createSwissRound pairs the active players of the tournament for the next round and stores the games together with the byes.
+// A draft round can still be edited by the owner and takes no results until it's published
+func createSwissRound(conn *pgx.Conn, tournament Tournament, draft bool) (int, []Round, error) {
+	lastRound, err := GetLastRoundNumber(conn, tournament.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	finished, err := IsRoundFinished(conn, tournament.ID, lastRound)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if !finished {
+		return 0, nil, ErrRoundNotFinished
+	}
+
+	roundNumber := lastRound + 1
+	ids, err := GetActivePlayerIDs(conn, tournament.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	requestedByes, err := getRequestedByes(conn, tournament.ID, roundNumber)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	_, history, err := GetRounds(conn, tournament.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	ratings, err := GetPlayerRatings(conn, tournament.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	forbidden, err := getForbiddenOpponents(conn, tournament.ID, roundNumber)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	players := make([]Player, 0)
+	for _, id := range ids {
+		if _, has := requestedByes[id]; has {
+			continue
+		}
+
+		index := GetIndexOfPlayer(history, id)
+		if index == -1 {
+			players = append(players, Player{Id: int64(id), Rating: ratings[id], Opponent: make(map[int64]struct{}), Forbidden: forbidden[id]})
+			continue
+		}
+
+		player := history[index]
+		player.Rating = ratings[id]
+		player.Forbidden = forbidden[id]
+		players = append(players, player)
+	}
+
+	// The virtual points of the accelerated rounds only go to the pairing, the standings use the results
+	var groupA map[int64]struct{}
+	newGroup := false
+	if virtualPoints := BakuVirtualPoints(roundNumber, tournament.Acceleration) * tournament.Scoring.Win; virtualPoints != 0 {
+		groupA, newGroup, err = getBakuGroup(conn, tournament.ID, ids)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		players = Accelerate(players, groupA, virtualPoints)
+	}
+
+	pairings, emptyPlayer, ok := CreateSwissRound(players, NewPairingSystem(tournament.PairingSystem))
+	if !ok {
+		return 0, nil, ErrNoPairing
+	}
+
+	var byes []Round
+	if emptyPlayer != 0 {
+		byes = append(byes, Round{Player1ID: int(emptyPlayer), Result: ResultBye})
+	}
+	for _, id := range ids {
+		if _, has := requestedByes[id]; has {
+			byes = append(byes, Round{Player1ID: id, Result: ResultRequestedBye})
+		}
+	}
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	rounds, err := insertRound(tx, tournament, roundNumber, roundGames(pairings, byes))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if newGroup {
+		if err = saveBakuGroup(tx, tournament.ID, groupA); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	if draft {
+		_, err = tx.Exec(context.Background(), "insert into round_states (tournament_id, round, published) values ($1, $2, false)",
+			tournament.ID, roundNumber)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	if err = tx.Commit(context.Background()); err != nil {
+		return 0, nil, err
+	}
+
+	return roundNumber, rounds, nil
+}
+
+// createRoundRobinRounds creates all the rounds of a round-robin that haven't been created yet.
+// The players take the seats of the Berger tables in the order of their rating
+func createRoundRobinRounds(conn *pgx.Conn, tournament Tournament) (int, []Round, error) {
+	lastRound, err := GetLastRoundNumber(conn, tournament.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	ids, err := GetActivePlayerIDs(conn, tournament.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	players := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		players = append(players, int64(id))
+	}
+
+	double := tournament.Format == FormatDoubleRoundRobin
+	totalRounds := roundrobin.Rounds(len(players), double)
+	if totalRounds == 0 {
+		return 0, nil, ErrNoPairing
+	}
+
+	if lastRound >= totalRounds {
+		return 0, nil, ErrScheduleComplete
+	}
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	rounds := make([]Round, 0)
+	for roundNumber := lastRound + 1; roundNumber <= totalRounds; roundNumber++ {
+		pairings, emptyPlayer := roundrobin.CreateRound(players, roundNumber, double)
+
+		var byes []Round
+		if emptyPlayer != 0 {
+			byes = append(byes, Round{Player1ID: int(emptyPlayer), Result: ResultBye})
+		}
+
+		created, err := insertRound(tx, tournament, roundNumber, roundGames(pairings, byes))
+		if err != nil {
+			return 0, nil, err
+		}
+
+		rounds = append(rounds, created...)
+	}
+
+	if err = tx.Commit(context.Background()); err != nil {
+		return 0, nil, err
+	}
+
+	return totalRounds, rounds, nil
+}
+
+// roundGames turns the pairings and the byes of a round into its games, the byes take the last boards
+func roundGames(pairings []Pairing, byes []Round) []Round {
+	games := make([]Round, 0, len(pairings)+len(byes))
+	for _, pairing := range pairings {
+		games = append(games, Round{Player1ID: int(pairing.White), Player2ID: int(pairing.Black), Player1Color: ColorWhite,
+			Player2Color: ColorBlack})
+	}
+
+	return append(games, byes...)
+}
+
+// nullable returns nil for the zero value, so that it's stored as null
+func nullable(value int) *int {
+	if value == 0 {
+		return nil
+	}
+
+	return &value
+}
+
+// insertRound stores the games of a round on consecutive boards. The first round of a tournament also starts it
+func insertRound(tx pgx.Tx, tournament Tournament, roundNumber int, games []Round) ([]Round, error) {
+	rounds := make([]Round, 0, len(games))
+	for i, round := range games {
+		round.Round, round.Board, round.TournamentID = roundNumber, i+1, tournament.ID
+
+		err := tx.QueryRow(context.Background(), "insert into rounds (round, board, pl_1, pl_2, pl_1_color, result, match, team_match, "+
+			"tournament_id) values ($1, $2, $3, $4, $5, $6, $7, $8, $9) returning id", round.Round, round.Board, round.Player1ID,
+			nullable(round.Player2ID), nullable(round.Player1Color), nullable(round.Result), nullable(round.Match), nullable(round.TeamMatch),
+			round.TournamentID).Scan(&round.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		rounds = append(rounds, round)
+	}
+
+	if tournament.Status != StatusInProgress {
+		_, err := tx.Exec(context.Background(), "update tournaments set status = $1, updated_at = current_timestamp where id = $2",
+			StatusInProgress, tournament.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rounds, nil
+}
+
 func CreateRounds(c *gin.Context) {
 	var information map[string]any // token && tournamentID
 	json.NewDecoder(c.Request.Body).Decode(&information)
@@ -114,57 +716,237 @@ func CreateRounds(c *gin.Context) {
 	}
 	defer conn.Close(context.Background())
 
-	ownerId, err := GetTournamentOwnerID(conn, tournamentID)
-	if err != nil {
+	if err = CreateRoundsTable(conn); err != nil {
 		log.Println(err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the id of the owner of the tournament"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the table for the rounds"})
 		return
 	}
 
-	if accType != Admin && id != ownerId {
+	tournament := Tournament{ID: tournamentID}
+	if err = tournament.GetTournament(conn); err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a tournament with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the information about the tournament"})
+		return
+	}
+
+	if accType != Admin && id != tournament.OwnerID {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Error only admins and the owner of the tournament can start the next rounds"})
 		return
 	}
 
-	rows, err := conn.Query(context.Background(), "select user_id from players where tournament_id = $1", tournamentID)
+	if tournament.IsOver() {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrTournamentOver.Error()})
+		return
+	}
+
+	if !tournament.CanPair() {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrCannotPair.Error()})
+		return
+	}
+
+	roundNumber, rounds, err := CreateNextRound(conn, tournament)
+	if err != nil {
+		switch err {
+		case ErrRoundNotFinished:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case ErrCutPending:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case ErrNoPairing, ErrShortRoster, ErrInvalidLineup, ErrPlayerInTwoTeams:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case ErrScheduleComplete:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the new rounds"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"round": roundNumber, "games": rounds})
+}
+
+// DeleteLastRound deletes the latest round of the tournament, so that it can be paired again. The scores come from
+// the remaining games. Rounds with results can only be deleted by an admin who forces it
+func DeleteLastRound(c *gin.Context) {
+	var information map[string]any
+	json.NewDecoder(c.Request.Body).Decode(&information) // token && tournamentID && (force) && (note)
+
+	token, ok := information["token"].(string)
+	if !ok {
+		log.Println("Incorrectly provided token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided token"})
+		return
+	}
+
+	id, accType, err := ValidateJWT(token)
 	if err != nil {
 		log.Println(err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error getting the ids of the players in the tournamet"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Error invalid token"})
 		return
 	}
 
-	ids := make([]int, 0)
-	for rows.Next() {
-		id := 0
-		err = rows.Scan(&id)
-		if err != nil {
+	tournamentIDFl, ok := information["tournamentID"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided id of the tournament")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return
+	}
+	tournamentID := int(tournamentIDFl)
+
+	force, _ := information["force"].(bool)
+	note, _ := information["note"].(string)
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	for _, create := range []func(*pgx.Conn) error{CreateRoundsTable, CreateRoundStatesTable, CreatePairingChangesTable, CreateTeamsTables} {
+		if err = create(conn); err != nil {
 			log.Println(err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error working with the ids of the users"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the tables for the rounds"})
 			return
 		}
+	}
 
-		ids = append(ids, id)
+	tournament := Tournament{ID: tournamentID}
+	if err = tournament.GetTournament(conn); err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a tournament with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the information about the tournament"})
+		return
 	}
 
-	if rows.Err() != nil {
-		log.Println(rows.Err())
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error working with the ids of the users"})
+	if accType != Admin && id != tournament.OwnerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Error only admins and the owner of the tournament can delete rounds"})
 		return
 	}
 
-	players := make([]Player, 0)
-	for _, id := range ids {
-		players = append(players, Player{Id: int64(id)})
+	if tournament.Status != StatusInProgress {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrNotInProgress.Error()})
+		return
 	}
 
-	rounds, emptyPlayer, ok := CreateSwissRound(players)
-	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the new rounds"})
+	// The whole schedule of a round-robin is created at once from the players of the tournament, creating a
+	// deleted round again would build a different schedule
+	if tournament.Format == FormatRoundRobin || tournament.Format == FormatDoubleRoundRobin {
+		c.JSON(http.StatusConflict, gin.H{"error": "Error the rounds of a round-robin can't be deleted"})
+		return
+	}
+
+	lastRound, err := GetLastRoundNumber(conn, tournamentID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the current round of the tournament"})
+		return
+	}
+
+	if lastRound == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Error the tournament doesn't have any rounds"})
+		return
+	}
+
+	results := 0
+	err = conn.QueryRow(context.Background(), "select count(*) from rounds where tournament_id = $1 and round = $2 and pl_2 is not null "+
+		"and (result is not null or reported_result is not null)", tournamentID, lastRound).Scan(&results)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to check the results of the round"})
+		return
+	}
+
+	if results > 0 && !(force && accType == Admin) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Error results have already been entered for the round, only an admin can force its deletion"})
+		return
+	}
+
+	if err = CreateAccelerationTable(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the table for the accelerated rounds"})
+		return
+	}
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to start a transaction"})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	_, err = tx.Exec(context.Background(), "delete from rounds where tournament_id = $1 and round = $2", tournamentID, lastRound)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to delete the round"})
+		return
+	}
+
+	_, err = tx.Exec(context.Background(), "delete from round_states where tournament_id = $1 and round = $2", tournamentID, lastRound)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to delete the round"})
+		return
+	}
+
+	_, err = tx.Exec(context.Background(), "delete from team_matches where tournament_id = $1 and round = $2", tournamentID, lastRound)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to delete the round"})
+		return
+	}
+
+	// The cut is made after the last Swiss round, so it isn't valid anymore without that round
+	if tournament.Format == FormatSwissKnockout && lastRound <= tournament.SwissRounds {
+		if err = SetSeeds(tx, tournamentID, nil); err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to reset the seeds of the bracket"})
+			return
+		}
+	}
+
+	if lastRound == 1 {
+		_, err = tx.Exec(context.Background(), "update tournaments set status = $1, updated_at = current_timestamp where id = $2",
+			StatusRegistrationClosed, tournamentID)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to update the status of the tournament"})
+			return
+		}
+
+		// Group A of the accelerated rounds is made again from the players of the new first round
+		_, err = tx.Exec(context.Background(), "delete from acceleration_groups where tournament_id = $1", tournamentID)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to delete the round"})
+			return
+		}
+	}
+
+	if err = saveChange(tx, tournamentID, PairingChange{Round: lastRound, ChangedBy: id, Action: ChangeDelete, Note: note}); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to save the change of the pairings"})
+		return
+	}
+
+	if err = tx.Commit(context.Background()); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to delete the round"})
 		return
 	}
 
-	log.Println(rounds)
-	log.Println(emptyPlayer)
+	c.JSON(http.StatusOK, gin.H{"round": lastRound})
 }
 
 func GetAllRounds(c *gin.Context) {
@@ -189,7 +971,8 @@ func GetAllRounds(c *gin.Context) {
 	}
 	defer conn.Close(context.Background())
 
-	rounds, _, err := GetRounds(conn, tournamentID)
+	// The pairings of a draft round are only shown once they're published
+	rounds, _, err := GetPublishedRounds(conn, tournamentID)
 	if err != nil {
 		log.Println(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the rounds"})
@@ -0,0 +1,542 @@
+package rounds
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	. "github.comPhantomvv1/SwissPairAPI/internal/auth"
+	. "github.comPhantomvv1/SwissPairAPI/internal/emails"
+	. "github.comPhantomvv1/SwissPairAPI/internal/tournament"
+)
+
+// RoundSchedule is the planned start of a round. The scheduler publishes the pairings of the round at its start
+type RoundSchedule struct {
+	TournamentID int        `json:"tournament_id"`
+	Round        int        `json:"round"`
+	StartAt      time.Time  `json:"start_at"`
+	TimeControl  string     `json:"time_control"`          // Minutes for the game and seconds of increment per move, like 90+30
+	Duration     int        `json:"duration"`              // Expected duration of the round in minutes
+	StartedAt    *time.Time `json:"started_at,omitempty"`  // When the scheduler started the round
+	NotifiedAt   *time.Time `json:"notified_at,omitempty"` // When the owner was told that the round couldn't be started
+}
+
+var (
+	ErrRoundStarted        = errors.New("Error the round has already been paired")
+	ErrScheduleInPast      = errors.New("Error the round can't be scheduled in the past")
+	ErrScheduleBeforeStart = errors.New("Error the round can't start before the tournament")
+	ErrScheduleOverlap     = errors.New("Error the round must start after the previous round is expected to end and end before the next one starts")
+	ErrPreviousRound       = errors.New("Error the previous round hasn't been paired yet")
+)
+
+var timeControlPattern = regexp.MustCompile(`^\d{1,3}(\+\d{1,3})?$`)
+
+func CreateRoundSchedulesTable(conn *pgx.Conn) error {
+	_, err := conn.Exec(context.Background(), "create table if not exists round_schedules (tournament_id int references tournaments(id), "+
+		"round int, start_at timestamp, time_control text, duration int, started_at timestamp, notified_at timestamp, "+
+		"unique (tournament_id, round))")
+
+	return err
+}
+
+// End returns when the round is expected to end
+func (s RoundSchedule) End() time.Time {
+	return s.StartAt.Add(time.Duration(s.Duration) * time.Minute)
+}
+
+// GetSchedule returns the scheduled rounds of the tournament in their order
+func GetSchedule(conn *pgx.Conn, tournamentID int) ([]RoundSchedule, error) {
+	rows, err := conn.Query(context.Background(), "select round, start_at, time_control, duration, started_at, notified_at "+
+		"from round_schedules where tournament_id = $1 order by round", tournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := make([]RoundSchedule, 0)
+	for rows.Next() {
+		round := RoundSchedule{TournamentID: tournamentID}
+		err = rows.Scan(&round.Round, &round.StartAt, &round.TimeControl, &round.Duration, &round.StartedAt, &round.NotifiedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		schedule = append(schedule, round)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return schedule, nil
+}
+
+// validateSchedule checks the planned round against the rounds that were already paired and the other scheduled rounds,
+// which have to be played one after another
+func validateSchedule(round RoundSchedule, schedule []RoundSchedule, tournament Tournament, lastRound int, now time.Time) error {
+	if round.Round <= lastRound {
+		return ErrRoundStarted
+	}
+
+	if round.StartAt.Before(now) {
+		return ErrScheduleInPast
+	}
+
+	if round.StartAt.Before(tournament.Start.UTC()) {
+		return ErrScheduleBeforeStart
+	}
+
+	for _, other := range schedule {
+		if other.Round < round.Round && other.End().After(round.StartAt) {
+			return ErrScheduleOverlap
+		}
+
+		if other.Round > round.Round && round.End().After(other.StartAt) {
+			return ErrScheduleOverlap
+		}
+	}
+
+	return nil
+}
+
+// publishScheduledDraft publishes the draft of the round at its time. The change is kept with the
+// changes of the owner, without an author
+func publishScheduledDraft(conn *pgx.Conn, tournamentID, round int) error {
+	if err := CreatePairingChangesTable(conn); err != nil {
+		return err
+	}
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	_, err = tx.Exec(context.Background(), "update round_states set published = true, published_by = null, published_at = current_timestamp "+
+		"where tournament_id = $1 and round = $2", tournamentID, round)
+	if err != nil {
+		return err
+	}
+
+	if err = saveChange(tx, tournamentID, PairingChange{Round: round, Action: ChangePublish, Note: "Published by the schedule"}); err != nil {
+		return err
+	}
+
+	return tx.Commit(context.Background())
+}
+
+// startScheduledRound publishes the pairings of the scheduled round. A draft of the round is published as it is,
+// otherwise the round is paired when all the previous results are in
+func startScheduledRound(conn *pgx.Conn, tournament Tournament, round int) error {
+	if !tournament.CanPair() {
+		return ErrCannotPair
+	}
+
+	draft, err := GetDraftRound(conn, tournament.ID)
+	if err != nil {
+		return err
+	}
+
+	if draft == round {
+		return publishScheduledDraft(conn, tournament.ID, round)
+	}
+
+	if draft != 0 {
+		return ErrDraftPending
+	}
+
+	lastRound, err := GetLastRoundNumber(conn, tournament.ID)
+	if err != nil {
+		return err
+	}
+
+	// The round was paired by the owner before its time or together with the whole schedule of a round-robin
+	if lastRound >= round {
+		return nil
+	}
+
+	if lastRound+1 < round {
+		return ErrPreviousRound
+	}
+
+	_, _, err = CreateNextRound(conn, tournament)
+	return err
+}
+
+// startScheduledRounds starts the rounds whose time has come. When a round can't be started its owner is told once
+// and the round is started as soon as it can be
+func startScheduledRounds(conn *pgx.Conn, now time.Time) error {
+	// The scheduler can run before anything is stored, so it creates all the tables it reads
+	for _, create := range []func(*pgx.Conn) error{CreateAuthTable, CreateTournamentsTable, CreateRoundsTable, CreateRoundStatesTable,
+		CreateRoundSchedulesTable} {
+		if err := create(conn); err != nil {
+			return err
+		}
+	}
+
+	rows, err := conn.Query(context.Background(), "select s.tournament_id, s.round, s.notified_at is not null from round_schedules s "+
+		"join tournaments t on t.id = s.tournament_id where s.started_at is null and s.start_at <= $1 and not (t.status = any($2)) "+
+		"order by s.start_at", now, []int{StatusFinished, StatusArchived, StatusCancelled})
+	if err != nil {
+		return err
+	}
+
+	type dueRound struct {
+		tournamentID, round int
+		notified            bool
+	}
+
+	due := make([]dueRound, 0)
+	for rows.Next() {
+		round := dueRound{}
+		if err = rows.Scan(&round.tournamentID, &round.round, &round.notified); err != nil {
+			return err
+		}
+
+		due = append(due, round)
+	}
+
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	for _, round := range due {
+		tournament := Tournament{ID: round.tournamentID}
+		if err = tournament.GetTournament(conn); err != nil {
+			log.Println(err)
+			continue
+		}
+
+		startErr := startScheduledRound(conn, tournament, round.round)
+		if startErr == nil {
+			_, err = conn.Exec(context.Background(), "update round_schedules set started_at = $1 where tournament_id = $2 and round = $3",
+				now, round.tournamentID, round.round)
+			if err != nil {
+				log.Println(err)
+			}
+			continue
+		}
+
+		log.Printf("Unable to start round %d of tournament %d: %v", round.round, round.tournamentID, startErr)
+		if round.notified {
+			continue
+		}
+
+		ownerEmail := ""
+		err = conn.QueryRow(context.Background(), "select email from authentication where id = $1", tournament.OwnerID).Scan(&ownerEmail)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		reason := strings.TrimPrefix(startErr.Error(), "Error ")
+		if err = RoundNotStartedEmail(ownerEmail, tournament.Name, round.round, reason); err != nil {
+			log.Println(err)
+			continue
+		}
+
+		_, err = conn.Exec(context.Background(), "update round_schedules set notified_at = $1 where tournament_id = $2 and round = $3",
+			now, round.tournamentID, round.round)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
+	return nil
+}
+
+// RunScheduler starts the scheduled rounds of all the tournaments, checking for them at every interval
+func RunScheduler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		if err = startScheduledRounds(conn, time.Now().UTC()); err != nil {
+			log.Println(err)
+		}
+
+		conn.Close(context.Background())
+	}
+}
+
+// SetRoundSchedule plans the start of a round that hasn't been paired yet or changes its plan
+func SetRoundSchedule(c *gin.Context) {
+	var information map[string]any
+	json.NewDecoder(c.Request.Body).Decode(&information) // token && tournamentID && round && start && timeControl && duration
+
+	token, ok := information["token"].(string)
+	if !ok {
+		log.Println("Incorrectly provided token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided token"})
+		return
+	}
+
+	id, accType, err := ValidateJWT(token)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Error invalid token"})
+		return
+	}
+
+	tournamentIDFl, ok := information["tournamentID"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided id of the tournament")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return
+	}
+	tournamentID := int(tournamentIDFl)
+
+	roundFl, ok := information["round"].(float64)
+	if !ok || roundFl < 1 {
+		log.Println("Incorrectly provided round")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided round"})
+		return
+	}
+
+	start, ok := information["start"].(string)
+	if !ok {
+		log.Println("Incorrectly provided start of the round")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided start of the round"})
+		return
+	}
+
+	startTS, err := time.Parse(time.RFC3339, start)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error the start of the round must be in the RFC3339 format"})
+		return
+	}
+
+	timeControl, ok := information["timeControl"].(string)
+	if !ok || !timeControlPattern.MatchString(timeControl) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error the time control must be the minutes of the game and optionally the seconds of increment, like 90+30"})
+		return
+	}
+
+	durationFl, ok := information["duration"].(float64)
+	if !ok || durationFl < 1 || durationFl > 24*60 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error the expected duration of the round must be between 1 and 1440 minutes"})
+		return
+	}
+
+	round := RoundSchedule{TournamentID: tournamentID, Round: int(roundFl), StartAt: startTS.UTC(), TimeControl: timeControl,
+		Duration: int(durationFl)}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	for _, create := range []func(*pgx.Conn) error{CreateRoundsTable, CreateRoundSchedulesTable} {
+		if err = create(conn); err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the tables for the rounds"})
+			return
+		}
+	}
+
+	tournament := Tournament{ID: tournamentID}
+	if err = tournament.GetTournament(conn); err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a tournament with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the information about the tournament"})
+		return
+	}
+
+	if accType != Admin && id != tournament.OwnerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Error only admins and the owner of the tournament can schedule rounds"})
+		return
+	}
+
+	if tournament.IsOver() {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrTournamentOver.Error()})
+		return
+	}
+
+	lastRound, err := GetLastRoundNumber(conn, tournamentID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the current round of the tournament"})
+		return
+	}
+
+	schedule, err := GetSchedule(conn, tournamentID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the schedule of the tournament"})
+		return
+	}
+
+	if err = validateSchedule(round, schedule, tournament, lastRound, time.Now().UTC()); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	// A changed plan is started and notified about again
+	_, err = conn.Exec(context.Background(), "insert into round_schedules (tournament_id, round, start_at, time_control, duration) "+
+		"values ($1, $2, $3, $4, $5) on conflict (tournament_id, round) do update set start_at = excluded.start_at, "+
+		"time_control = excluded.time_control, duration = excluded.duration, started_at = null, notified_at = null",
+		tournamentID, round.Round, round.StartAt, round.TimeControl, round.Duration)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to save the schedule of the round"})
+		return
+	}
+
+	c.JSON(http.StatusOK, round)
+}
+
+func GetRoundSchedule(c *gin.Context) {
+	tournamentID, err := strconv.Atoi(c.Param("tournamentID"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if err = CreateRoundSchedulesTable(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the table for the schedule of the rounds"})
+		return
+	}
+
+	schedule, err := GetSchedule(conn, tournamentID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the schedule of the tournament"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// RemoveRoundSchedule takes a round that hasn't been paired yet off the schedule, so that it's only started by hand
+func RemoveRoundSchedule(c *gin.Context) {
+	var information map[string]any
+	json.NewDecoder(c.Request.Body).Decode(&information) // token && tournamentID && round
+
+	token, ok := information["token"].(string)
+	if !ok {
+		log.Println("Incorrectly provided token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided token"})
+		return
+	}
+
+	id, accType, err := ValidateJWT(token)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Error invalid token"})
+		return
+	}
+
+	tournamentIDFl, ok := information["tournamentID"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided id of the tournament")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return
+	}
+	tournamentID := int(tournamentIDFl)
+
+	roundFl, ok := information["round"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided round")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided round"})
+		return
+	}
+	round := int(roundFl)
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	for _, create := range []func(*pgx.Conn) error{CreateRoundsTable, CreateRoundSchedulesTable} {
+		if err = create(conn); err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the tables for the rounds"})
+			return
+		}
+	}
+
+	tournament := Tournament{ID: tournamentID}
+	if err = tournament.GetTournament(conn); err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a tournament with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the information about the tournament"})
+		return
+	}
+
+	if accType != Admin && id != tournament.OwnerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Error only admins and the owner of the tournament can change the schedule"})
+		return
+	}
+
+	if tournament.IsOver() {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrTournamentOver.Error()})
+		return
+	}
+
+	lastRound, err := GetLastRoundNumber(conn, tournamentID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the current round of the tournament"})
+		return
+	}
+
+	if round <= lastRound {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrRoundStarted.Error()})
+		return
+	}
+
+	removed := 0
+	err = conn.QueryRow(context.Background(), "delete from round_schedules where tournament_id = $1 and round = $2 returning round",
+		tournamentID, round).Scan(&removed)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error the round isn't scheduled"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to remove the round from the schedule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, nil)
+}
@@ -0,0 +1,316 @@
+package rounds
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	. "github.comPhantomvv1/SwissPairAPI/internal/swiss"
+	. "github.comPhantomvv1/SwissPairAPI/internal/teams"
+	. "github.comPhantomvv1/SwissPairAPI/internal/tournament"
+)
+
+// TeamMatch is a match of two teams played on all the boards of the tournament. Team 1 has white on
+// the odd boards and black on the even ones. A team without an opponent has a bye
+type TeamMatch struct {
+	ID           int     `json:"id"`
+	Round        int     `json:"round"`
+	Board        int     `json:"board"`
+	Team1ID      int     `json:"team_1_id"`
+	Team2ID      int     `json:"team_2_id"`
+	Team1Points  float64 `json:"team_1_points"` // Board points, the points of the players of team 1 in their games
+	Team2Points  float64 `json:"team_2_points"`
+	Finished     bool    `json:"finished"` // Whether all the games of the match have a result
+	TournamentID int     `json:"tournament_id"`
+}
+
+// Match points of the teams for a won and a drawn match, a lost match is worth nothing
+const (
+	TeamMatchWin  = 2
+	TeamMatchDraw = 1
+)
+
+var ErrPlayerInTwoTeams = errors.New("Error a player is in the lineups of two teams")
+
+// BoardPoints returns the points the players of the team scored in the match
+func (m TeamMatch) BoardPoints(teamID int) float64 {
+	if teamID == m.Team1ID {
+		return m.Team1Points
+	}
+
+	return m.Team2Points
+}
+
+// MatchPoints returns the match points of the team, the team with more board points wins the match.
+// A bye is worth the bye value of the tournament in match points too
+func (m TeamMatch) MatchPoints(teamID int, tournament Tournament) float64 {
+	if m.Team2ID == 0 {
+		return tournament.ByeValue * TeamMatchWin
+	}
+
+	if !m.Finished {
+		return 0
+	}
+
+	own, opponent := m.BoardPoints(teamID), m.BoardPoints(m.Opponent(teamID))
+	switch {
+	case own > opponent:
+		return TeamMatchWin
+	case own == opponent:
+		return TeamMatchDraw
+	}
+
+	return 0
+}
+
+// Score returns the points of the team in the match by the primary criterion of the tournament
+func (m TeamMatch) Score(teamID int, tournament Tournament) float64 {
+	if tournament.TeamRanking == TeamRankingBoardPoints {
+		return m.BoardPoints(teamID)
+	}
+
+	return m.MatchPoints(teamID, tournament)
+}
+
+// Opponent returns the id of the team that played against the team or 0 for a bye
+func (m TeamMatch) Opponent(teamID int) int {
+	if teamID == m.Team1ID {
+		return m.Team2ID
+	}
+
+	return m.Team1ID
+}
+
+// GetTeamMatches returns the team matches of the tournament with the board points from the results of their games
+func GetTeamMatches(conn *pgx.Conn, tournament Tournament) ([]TeamMatch, error) {
+	rows, err := conn.Query(context.Background(), "select id, round, board, team_1, team_2 from team_matches where tournament_id = $1 "+
+		"order by round, board", tournament.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]TeamMatch, 0)
+	indexes := make(map[int]int)
+	for rows.Next() {
+		match := TeamMatch{TournamentID: tournament.ID, Finished: true}
+		var team2 *int
+		if err = rows.Scan(&match.ID, &match.Round, &match.Board, &match.Team1ID, &team2); err != nil {
+			return nil, err
+		}
+
+		if team2 != nil {
+			match.Team2ID = *team2
+		} else {
+			match.Team1Points = float64(tournament.TeamBoards) * tournament.ByeValue * tournament.Scoring.Win
+		}
+
+		indexes[match.ID] = len(matches)
+		matches = append(matches, match)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	games, _, err := GetPublishedRounds(conn, tournament.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, game := range games {
+		index, ok := indexes[game.TeamMatch]
+		if !ok {
+			continue
+		}
+
+		if game.Result == 0 {
+			matches[index].Finished = false
+			continue
+		}
+
+		matches[index].Team1Points += game.Points(game.Player1ID, tournament)
+		matches[index].Team2Points += game.Points(game.Player2ID, tournament)
+	}
+
+	return matches, nil
+}
+
+// teamHistory builds the pairing history of the teams from their matches, the same way GetRounds does it for the players
+func teamHistory(matches []TeamMatch, tournament Tournament) []Player {
+	teams := make([]Player, 0)
+	scoresBefore := make(map[int]float64)
+	currentRound := 0
+	for _, match := range matches {
+		if match.Round != currentRound {
+			for _, team := range teams {
+				scoresBefore[int(team.Id)] = team.Score
+			}
+			currentRound = match.Round
+		}
+
+		index1 := getPlayer(&teams, match.Team1ID)
+		if match.Team2ID == 0 {
+			teams[index1].Score += match.Score(match.Team1ID, tournament)
+			teams[index1].HadBye = true
+			setFloat(&teams[index1], match.Round, FloatDown)
+			continue
+		}
+
+		index2 := getPlayer(&teams, match.Team2ID)
+		teams[index1].Opponent[int64(match.Team2ID)] = struct{}{}
+		teams[index2].Opponent[int64(match.Team1ID)] = struct{}{}
+		teams[index1].Colors = append(teams[index1].Colors, ColorWhite)
+		teams[index2].Colors = append(teams[index2].Colors, ColorBlack)
+
+		switch {
+		case scoresBefore[match.Team1ID] > scoresBefore[match.Team2ID]:
+			setFloat(&teams[index1], match.Round, FloatDown)
+			setFloat(&teams[index2], match.Round, FloatUp)
+		case scoresBefore[match.Team1ID] < scoresBefore[match.Team2ID]:
+			setFloat(&teams[index1], match.Round, FloatUp)
+			setFloat(&teams[index2], match.Round, FloatDown)
+		default:
+			setFloat(&teams[index1], match.Round, FloatNone)
+			setFloat(&teams[index2], match.Round, FloatNone)
+		}
+
+		teams[index1].Score += match.Score(match.Team1ID, tournament)
+		teams[index2].Score += match.Score(match.Team2ID, tournament)
+	}
+
+	for i := range teams {
+		setFloat(&teams[i], currentRound, FloatNone)
+	}
+
+	return teams
+}
+
+// boardGames expands a team match into its games. The team with white in the pairing has white on the first board
+// and the colours alternate from board to board
+func boardGames(matchID int, lineup1, lineup2 []int) []Round {
+	games := make([]Round, 0, len(lineup1))
+	for board := range lineup1 {
+		game := Round{Player1ID: lineup1[board], Player2ID: lineup2[board], Player1Color: ColorWhite, Player2Color: ColorBlack,
+			TeamMatch: matchID}
+		if board%2 == 1 {
+			game.Player1Color, game.Player2Color = ColorBlack, ColorWhite
+		}
+
+		games = append(games, game)
+	}
+
+	return games
+}
+
+// createTeamRound pairs the teams of the tournament for the next round with the Swiss system, scored by the primary criterion
+// of the tournament. Every team match is stored with the games of its boards, played by the lineups of the teams
+func createTeamRound(conn *pgx.Conn, tournament Tournament) (int, []Round, error) {
+	if err := CreateTeamsTables(conn); err != nil {
+		return 0, nil, err
+	}
+
+	lastRound, err := GetLastTeamRound(conn, tournament.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	finished, err := IsRoundFinished(conn, tournament.ID, lastRound)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if !finished {
+		return 0, nil, ErrRoundNotFinished
+	}
+
+	roundNumber := lastRound + 1
+	entered, err := GetTournamentTeams(conn, tournament.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if len(entered) < 2 {
+		return 0, nil, ErrNoPairing
+	}
+
+	matches, err := GetTeamMatches(conn, tournament)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	history := teamHistory(matches, tournament)
+	teams := make([]Player, 0, len(entered))
+	for _, team := range entered {
+		index := GetIndexOfPlayer(history, team.TeamID)
+		if index == -1 {
+			teams = append(teams, Player{Id: int64(team.TeamID), Rating: team.Rating, Opponent: make(map[int64]struct{})})
+			continue
+		}
+
+		player := history[index]
+		player.Rating = team.Rating
+		teams = append(teams, player)
+	}
+
+	pairings, emptyTeam, ok := CreateSwissRound(teams, NewPairingSystem(tournament.PairingSystem))
+	if !ok {
+		return 0, nil, ErrNoPairing
+	}
+
+	lineups := make(map[int][]int)
+	seen := make(map[int]struct{})
+	for _, pairing := range pairings {
+		for _, teamID := range []int{int(pairing.White), int(pairing.Black)} {
+			lineup, err := GetLineup(conn, tournament.ID, teamID, roundNumber, tournament.TeamBoards)
+			if err != nil {
+				return 0, nil, err
+			}
+
+			for _, id := range lineup {
+				if _, has := seen[id]; has {
+					return 0, nil, ErrPlayerInTwoTeams
+				}
+				seen[id] = struct{}{}
+			}
+
+			lineups[teamID] = lineup
+		}
+	}
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	games := make([]Round, 0, len(pairings)*tournament.TeamBoards)
+	for i, pairing := range pairings {
+		matchID := 0
+		err = tx.QueryRow(context.Background(), "insert into team_matches (tournament_id, round, board, team_1, team_2) values "+
+			"($1, $2, $3, $4, $5) returning id", tournament.ID, roundNumber, i+1, pairing.White, pairing.Black).Scan(&matchID)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		games = append(games, boardGames(matchID, lineups[int(pairing.White)], lineups[int(pairing.Black)])...)
+	}
+
+	if emptyTeam != 0 {
+		_, err = tx.Exec(context.Background(), "insert into team_matches (tournament_id, round, board, team_1) values ($1, $2, $3, $4)",
+			tournament.ID, roundNumber, len(pairings)+1, emptyTeam)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	rounds, err := insertRound(tx, tournament, roundNumber, games)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err = tx.Commit(context.Background()); err != nil {
+		return 0, nil, err
+	}
+
+	return roundNumber, rounds, nil
+}
@@ -0,0 +1,82 @@
+package standings
+
+import (
+	. "github.comPhantomvv1/SwissPairAPI/internal/rounds"
+	. "github.comPhantomvv1/SwissPairAPI/internal/tournament"
+)
+
+// percentageFloor is the lowest match and game win percentage a player can have in the tiebreaks,
+// so that playing against players who lost most of their matches isn't punished too much
+const percentageFloor = 1.0 / 3
+
+// winPercentages are the match and game win percentages of the players, used by the tiebreaks of card game events
+type winPercentages struct {
+	match map[int]float64
+	game  map[int]float64
+}
+
+// matchWinPercentage returns the share of the points of a win the player scored in their matches. Byes count
+// as matches won, requested byes and cancelled games don't count at all
+func matchWinPercentage(playerID int, games []Round, tournament Tournament) float64 {
+	points, possible := 0.0, 0.0
+	for _, game := range games {
+		if game.Result == 0 || game.Result == ResultRequestedBye || game.Result == ResultUnplayed {
+			continue
+		}
+
+		points += game.Points(playerID, tournament)
+		possible += tournament.Scoring.Win
+	}
+
+	if possible == 0 {
+		return percentageFloor
+	}
+
+	return max(points/possible, percentageFloor)
+}
+
+// gameWinPercentage returns the share of the points of a win the player scored in the single games of their matches
+func gameWinPercentage(playerID int, games []Round, tournament Tournament) float64 {
+	points, possible := 0.0, 0.0
+	for _, game := range games {
+		won, lost, drawn := game.Games(playerID, tournament.BestOf)
+		points += float64(won)*tournament.Scoring.Win + float64(drawn)*tournament.Scoring.Draw
+		possible += float64(won+lost+drawn) * tournament.Scoring.Win
+	}
+
+	if possible == 0 {
+		return percentageFloor
+	}
+
+	return max(points/possible, percentageFloor)
+}
+
+// calculateWinPercentages calculates the win percentages of all the players who have games
+func calculateWinPercentages(games map[int][]Round, tournament Tournament) winPercentages {
+	percentages := winPercentages{match: make(map[int]float64), game: make(map[int]float64)}
+	for id, playerGames := range games {
+		percentages.match[id] = matchWinPercentage(id, playerGames, tournament)
+		percentages.game[id] = gameWinPercentage(id, playerGames, tournament)
+	}
+
+	return percentages
+}
+
+// opponentAverage returns the average of the percentages of the opponents the player met over the board
+func opponentAverage(playerID int, games []Round, percentages map[int]float64) float64 {
+	total, opponents := 0.0, 0
+	for _, game := range games {
+		if !game.IsPlayed() {
+			continue
+		}
+
+		total += percentages[game.Opponent(playerID)]
+		opponents++
+	}
+
+	if opponents == 0 {
+		return 0
+	}
+
+	return total / float64(opponents)
+}
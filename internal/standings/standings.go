@@ -0,0 +1,426 @@
+package standings
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	. "github.comPhantomvv1/SwissPairAPI/internal/auth"
+	. "github.comPhantomvv1/SwissPairAPI/internal/rounds"
+	. "github.comPhantomvv1/SwissPairAPI/internal/swiss"
+	. "github.comPhantomvv1/SwissPairAPI/internal/tournament"
+)
+
+type Standing struct {
+	Rank      int                `json:"rank"`
+	PlayerID  int                `json:"player_id"`
+	Points    float64            `json:"points"`
+	Tiebreaks map[string]float64 `json:"tiebreaks"`
+	Withdrawn bool               `json:"withdrawn,omitempty"`
+}
+
+// playerGames returns the games of every player in the order of the rounds
+func playerGames(rounds []Round) map[int][]Round {
+	games := make(map[int][]Round)
+	for _, round := range rounds {
+		games[round.Player1ID] = append(games[round.Player1ID], round)
+		if round.Player2ID != 0 {
+			games[round.Player2ID] = append(games[round.Player2ID], round)
+		}
+	}
+
+	return games
+}
+
+// opponentScores returns the final score of the opponent in every round of the player. Rounds without
+// a game played over the board, like byes and forfeits, count as a game against an opponent with the player's own score
+func opponentScores(playerID int, games []Round, points map[int]float64) []float64 {
+	scores := make([]float64, 0, len(games))
+	for _, game := range games {
+		if !game.IsPlayed() {
+			scores = append(scores, points[playerID])
+			continue
+		}
+
+		scores = append(scores, points[game.Opponent(playerID)])
+	}
+
+	return scores
+}
+
+func sum(values []float64) float64 {
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+
+	return total
+}
+
+// cut returns the sum of the values without the given number of lowest and highest values
+func cut(values []float64, lowest, highest int) float64 {
+	if len(values) <= lowest+highest {
+		return 0
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	return sum(sorted[lowest : len(sorted)-highest])
+}
+
+// computeTiebreak calculates a tiebreak that only depends on the games of the player
+func computeTiebreak(tiebreak string, playerID int, games []Round, points map[int]float64, percentages winPercentages,
+	tournament Tournament) float64 {
+	switch tiebreak {
+	case TiebreakBuchholz:
+		return sum(opponentScores(playerID, games, points))
+	case TiebreakBuchholzCut1:
+		return cut(opponentScores(playerID, games, points), 1, 0)
+	case TiebreakMedianBuchholz:
+		return cut(opponentScores(playerID, games, points), 1, 1)
+	case TiebreakSonnebornBerger:
+		total := 0.0
+		for _, game := range games {
+			if game.IsPlayed() {
+				total += game.Points(playerID, tournament) * points[game.Opponent(playerID)]
+			}
+		}
+		return total
+	case TiebreakProgressiveScore:
+		total, running := 0.0, 0.0
+		for _, game := range games {
+			running += game.Points(playerID, tournament)
+			total += running
+		}
+		return total
+	case TiebreakWins:
+		wins := 0.0
+		for _, game := range games {
+			if game.IsPlayed() && game.GameScore(playerID) == 1 {
+				wins++
+			}
+		}
+		return wins
+	case TiebreakOpponentMatchWin:
+		return opponentAverage(playerID, games, percentages.match)
+	case TiebreakGameWin:
+		return percentages.game[playerID]
+	case TiebreakOpponentGameWin:
+		return opponentAverage(playerID, games, percentages.game)
+	case TiebreakBlacks:
+		blacks := 0.0
+		for _, game := range games {
+			if game.IsPlayed() && game.Color(playerID) == ColorBlack {
+				blacks++
+			}
+		}
+		return blacks
+	}
+
+	return 0
+}
+
+// directEncounter calculates the points every player scored against the other players of the group
+func directEncounter(group []Standing, games map[int][]Round, tournament Tournament) {
+	members := make(map[int]struct{})
+	for _, standing := range group {
+		members[standing.PlayerID] = struct{}{}
+	}
+
+	for _, standing := range group {
+		total := 0.0
+		for _, game := range games[standing.PlayerID] {
+			if _, has := members[game.Opponent(standing.PlayerID)]; has {
+				total += game.Points(standing.PlayerID, tournament)
+			}
+		}
+
+		standing.Tiebreaks[TiebreakDirectEncounter] = total
+	}
+}
+
+// tied reports whether the two standings are equal on the points and the first count tiebreaks
+func tied(a, b Standing, tiebreaks []string, count int) bool {
+	if a.Points != b.Points {
+		return false
+	}
+
+	for _, tiebreak := range tiebreaks[:count] {
+		if a.Tiebreaks[tiebreak] != b.Tiebreaks[tiebreak] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sortStandings(standings []Standing, tiebreaks []string) {
+	sort.SliceStable(standings, func(i, j int) bool {
+		if standings[i].Points != standings[j].Points {
+			return standings[i].Points > standings[j].Points
+		}
+
+		for _, tiebreak := range tiebreaks {
+			if standings[i].Tiebreaks[tiebreak] != standings[j].Tiebreaks[tiebreak] {
+				return standings[i].Tiebreaks[tiebreak] > standings[j].Tiebreaks[tiebreak]
+			}
+		}
+
+		return false
+	})
+}
+
+// CalculateStandings ranks the players by points and then by the tiebreaks of the tournament in their order
+func CalculateStandings(tournament Tournament, playerIDs []int, rounds []Round) []Standing {
+	games := playerGames(rounds)
+
+	points := make(map[int]float64)
+	for _, round := range rounds {
+		points[round.Player1ID] += round.Points(round.Player1ID, tournament)
+		if round.Player2ID != 0 {
+			points[round.Player2ID] += round.Points(round.Player2ID, tournament)
+		}
+	}
+
+	percentages := calculateWinPercentages(games, tournament)
+
+	standings := make([]Standing, 0, len(playerIDs))
+	for _, id := range playerIDs {
+		standing := Standing{PlayerID: id, Points: points[id], Tiebreaks: make(map[string]float64)}
+		for _, tiebreak := range tournament.Tiebreaks {
+			if tiebreak != TiebreakDirectEncounter {
+				standing.Tiebreaks[tiebreak] = computeTiebreak(tiebreak, id, games[id], points, percentages, tournament)
+			}
+		}
+
+		standings = append(standings, standing)
+	}
+
+	// The direct encounter depends on which players are still tied, so it's calculated
+	// for the groups that are equal on everything before it
+	for i, tiebreak := range tournament.Tiebreaks {
+		if tiebreak != TiebreakDirectEncounter {
+			continue
+		}
+
+		sortStandings(standings, tournament.Tiebreaks[:i])
+		for start := 0; start < len(standings); {
+			end := start + 1
+			for end < len(standings) && tied(standings[start], standings[end], tournament.Tiebreaks, i) {
+				end++
+			}
+
+			directEncounter(standings[start:end], games, tournament)
+			start = end
+		}
+	}
+
+	sortStandings(standings, tournament.Tiebreaks)
+	for i := range standings {
+		standings[i].Rank = i + 1
+		if i > 0 && tied(standings[i-1], standings[i], tournament.Tiebreaks, len(tournament.Tiebreaks)) {
+			standings[i].Rank = standings[i-1].Rank
+		}
+	}
+
+	return standings
+}
+
+// GetStandings loads the tournament with its games and calculates the current standings
+func GetStandings(conn *pgx.Conn, tournamentID int) (Tournament, []Standing, error) {
+	tournament := Tournament{ID: tournamentID}
+	if err := tournament.GetTournament(conn); err != nil {
+		return Tournament{}, nil, err
+	}
+
+	playerIDs, err := GetPlayerIDs(conn, tournamentID)
+	if err != nil {
+		return Tournament{}, nil, err
+	}
+
+	rounds, _, err := GetPublishedRounds(conn, tournamentID)
+	if err != nil {
+		return Tournament{}, nil, err
+	}
+
+	withdrawn, err := GetWithdrawnPlayers(conn, tournamentID)
+	if err != nil {
+		return Tournament{}, nil, err
+	}
+
+	standings := CalculateStandings(tournament, playerIDs, rounds)
+	for i := range standings {
+		_, standings[i].Withdrawn = withdrawn[standings[i].PlayerID]
+	}
+
+	return tournament, standings, nil
+}
+
+func GetTournamentStandings(c *gin.Context) {
+	tournamentID, err := strconv.Atoi(c.Param("tournamentID"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if err = CreateRoundsTable(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the table for the rounds"})
+		return
+	}
+
+	tournament, standings, err := GetStandings(conn, tournamentID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a tournament with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to calculate the standings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tiebreaks": tournament.Tiebreaks, "standings": standings})
+}
+
+// MakeCut seeds the best players of the Swiss rounds into the knockout bracket in the order of the standings
+func MakeCut(c *gin.Context) {
+	var information map[string]any
+	json.NewDecoder(c.Request.Body).Decode(&information) // token && tournamentID
+
+	token, ok := information["token"].(string)
+	if !ok {
+		log.Println("Incorrectly provided token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided token"})
+		return
+	}
+
+	id, accType, err := ValidateJWT(token)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Error invalid token"})
+		return
+	}
+
+	tournamentIDFl, ok := information["tournamentID"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided id of the tournament")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return
+	}
+	tournamentID := int(tournamentIDFl)
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if err = CreateRoundsTable(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the table for the rounds"})
+		return
+	}
+
+	tournament, standings, err := GetStandings(conn, tournamentID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a tournament with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to calculate the standings"})
+		return
+	}
+
+	if accType != Admin && id != tournament.OwnerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Error only admins and the owner of the tournament can make the cut"})
+		return
+	}
+
+	if tournament.Status != StatusInProgress {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrNotInProgress.Error()})
+		return
+	}
+
+	if tournament.Format != FormatSwissKnockout {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error the tournament doesn't have a cut after the Swiss rounds"})
+		return
+	}
+
+	lastRound, err := GetLastRoundNumber(conn, tournamentID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the current round of the tournament"})
+		return
+	}
+
+	finished, err := IsRoundFinished(conn, tournamentID, lastRound)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to check if the round is finished"})
+		return
+	}
+
+	if lastRound != tournament.SwissRounds || !finished {
+		c.JSON(http.StatusConflict, gin.H{"error": "Error the Swiss rounds of the tournament haven't finished yet"})
+		return
+	}
+
+	seeds := make([]int64, 0, tournament.Cut)
+	for _, standing := range standings {
+		if len(seeds) == tournament.Cut {
+			break
+		}
+
+		// Withdrawn players leave their place in the bracket to the next player
+		if standing.Withdrawn {
+			continue
+		}
+
+		seeds = append(seeds, int64(standing.PlayerID))
+	}
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to start a transaction"})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	if err = SetSeeds(tx, tournamentID, seeds); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to save the seeds of the bracket"})
+		return
+	}
+
+	if err = tx.Commit(context.Background()); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to save the seeds of the bracket"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"seeds": seeds})
+}
@@ -0,0 +1,138 @@
+package standings
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	. "github.comPhantomvv1/SwissPairAPI/internal/rounds"
+	. "github.comPhantomvv1/SwissPairAPI/internal/teams"
+	. "github.comPhantomvv1/SwissPairAPI/internal/tournament"
+)
+
+type TeamStanding struct {
+	Rank        int     `json:"rank"`
+	TeamID      int     `json:"team_id"`
+	Name        string  `json:"name"`
+	MatchPoints float64 `json:"match_points"`
+	BoardPoints float64 `json:"board_points"`
+}
+
+// criteria returns the primary and the secondary criterion of the team in the order of the tournament
+func (s TeamStanding) criteria(tournament Tournament) (float64, float64) {
+	if tournament.TeamRanking == TeamRankingBoardPoints {
+		return s.BoardPoints, s.MatchPoints
+	}
+
+	return s.MatchPoints, s.BoardPoints
+}
+
+// CalculateTeamStandings ranks the teams by the primary criterion of the tournament and then by the secondary one
+func CalculateTeamStandings(tournament Tournament, teams []TournamentTeam, matches []TeamMatch) []TeamStanding {
+	standings := make([]TeamStanding, 0, len(teams))
+	indexes := make(map[int]int)
+	for _, team := range teams {
+		indexes[team.TeamID] = len(standings)
+		standings = append(standings, TeamStanding{TeamID: team.TeamID, Name: team.Name})
+	}
+
+	for _, match := range matches {
+		for _, teamID := range []int{match.Team1ID, match.Team2ID} {
+			index, ok := indexes[teamID]
+			if !ok {
+				continue
+			}
+
+			standings[index].MatchPoints += match.MatchPoints(teamID, tournament)
+			standings[index].BoardPoints += match.BoardPoints(teamID)
+		}
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		primaryI, secondaryI := standings[i].criteria(tournament)
+		primaryJ, secondaryJ := standings[j].criteria(tournament)
+		if primaryI != primaryJ {
+			return primaryI > primaryJ
+		}
+
+		return secondaryI > secondaryJ
+	})
+
+	for i := range standings {
+		standings[i].Rank = i + 1
+		if i > 0 && standings[i].MatchPoints == standings[i-1].MatchPoints && standings[i].BoardPoints == standings[i-1].BoardPoints {
+			standings[i].Rank = standings[i-1].Rank
+		}
+	}
+
+	return standings
+}
+
+// GetTeamStandings loads the teams of the tournament with their matches and calculates the current standings
+func GetTeamStandings(conn *pgx.Conn, tournamentID int) (Tournament, []TeamStanding, error) {
+	tournament := Tournament{ID: tournamentID}
+	if err := tournament.GetTournament(conn); err != nil {
+		return Tournament{}, nil, err
+	}
+
+	teams, err := GetTournamentTeams(conn, tournamentID)
+	if err != nil {
+		return Tournament{}, nil, err
+	}
+
+	matches, err := GetTeamMatches(conn, tournament)
+	if err != nil {
+		return Tournament{}, nil, err
+	}
+
+	return tournament, CalculateTeamStandings(tournament, teams, matches), nil
+}
+
+func GetTournamentTeamStandings(c *gin.Context) {
+	tournamentID, err := strconv.Atoi(c.Param("tournamentID"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	for _, create := range []func(*pgx.Conn) error{CreateRoundsTable, CreateTeamsTables} {
+		if err = create(conn); err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the tables for the team matches"})
+			return
+		}
+	}
+
+	tournament, standings, err := GetTeamStandings(conn, tournamentID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a tournament with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to calculate the standings of the teams"})
+		return
+	}
+
+	if tournament.TeamBoards == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error the tournament isn't played by teams"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"team_ranking": tournament.TeamRanking, "standings": standings})
+}
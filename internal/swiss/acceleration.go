@@ -0,0 +1,38 @@
+package swiss
+
+// BakuGroupSize returns the number of players of group A in the Baku acceleration (FIDE C.04.5.1),
+// the top quarter of the field rounded up and doubled so that the group is even
+func BakuGroupSize(players int) int {
+	return 2 * ((players + 3) / 4)
+}
+
+// BakuVirtualPoints returns the virtual points of the players of group A in the given round. They get
+// one point in the first half of the accelerated rounds, half a point in the rest and none afterwards
+func BakuVirtualPoints(round, acceleratedRounds int) float64 {
+	switch {
+	case round > acceleratedRounds:
+		return 0
+	case round <= (acceleratedRounds+1)/2:
+		return 1
+	default:
+		return 0.5
+	}
+}
+
+// Accelerate returns the players with the virtual points added to the scores of the players of group A.
+// The virtual points only change the pairing, the results of the players stay the same
+func Accelerate(players []Player, groupA map[int64]struct{}, virtualPoints float64) []Player {
+	accelerated := make([]Player, len(players))
+	copy(accelerated, players)
+	if virtualPoints == 0 {
+		return accelerated
+	}
+
+	for i := range accelerated {
+		if _, has := groupA[accelerated[i].Id]; has {
+			accelerated[i].Score += virtualPoints
+		}
+	}
+
+	return accelerated
+}
@@ -0,0 +1,197 @@
+package swiss
+
+import "sort"
+
+const (
+	ColorWhite = iota + 1
+	ColorBlack
+)
+
+const (
+	PreferenceNone = iota
+	PreferenceMild
+	PreferenceStrong
+	PreferenceAbsolute
+)
+
+// Pairing is a game of the next round with the colours already allocated
+type Pairing struct {
+	White int64
+	Black int64
+}
+
+func OppositeColor(color int) int {
+	if color == ColorWhite {
+		return ColorBlack
+	}
+
+	return ColorWhite
+}
+
+// ColorDifference returns the number of games played with white minus the number of games played with black
+func (p Player) ColorDifference() int {
+	difference := 0
+	for _, color := range p.Colors {
+		switch color {
+		case ColorWhite:
+			difference++
+		case ColorBlack:
+			difference--
+		}
+	}
+
+	return difference
+}
+
+// ColorPreference returns the colour the player should get in the next round and how strong that preference is
+func (p Player) ColorPreference() (int, int) {
+	if len(p.Colors) == 0 {
+		return 0, PreferenceNone
+	}
+
+	difference := p.ColorDifference()
+	last := p.Colors[len(p.Colors)-1]
+
+	switch {
+	case difference < -1:
+		return ColorWhite, PreferenceAbsolute
+	case difference > 1:
+		return ColorBlack, PreferenceAbsolute
+	case len(p.Colors) >= 2 && p.Colors[len(p.Colors)-2] == last:
+		return OppositeColor(last), PreferenceAbsolute
+	case difference == -1:
+		return ColorWhite, PreferenceStrong
+	case difference == 1:
+		return ColorBlack, PreferenceStrong
+	}
+
+	return OppositeColor(last), PreferenceMild
+}
+
+// colorConflict reports whether the two players have an absolute preference for the same colour
+func colorConflict(a, b Player) bool {
+	colorA, strengthA := a.ColorPreference()
+	colorB, strengthB := b.ColorPreference()
+
+	return strengthA == PreferenceAbsolute && strengthB == PreferenceAbsolute && colorA == colorB
+}
+
+// withColorConflicts returns copies of the players where the players with the same absolute colour
+// preference are treated as previous opponents, so that the pairing systems avoid them
+func withColorConflicts(players []Player) []Player {
+	result := make([]Player, len(players))
+	for i, player := range players {
+		opponents := make(map[int64]struct{})
+		for id := range player.Opponent {
+			opponents[id] = struct{}{}
+		}
+
+		for _, other := range players {
+			if other.Id != player.Id && colorConflict(player, other) {
+				opponents[other.Id] = struct{}{}
+			}
+		}
+
+		player.Opponent = opponents
+		result[i] = player
+	}
+
+	return result
+}
+
+// allocateColor decides which of the two players gets white. The higher player is the one with
+// the better rank, initialWhite is the colour the higher player gets when nothing else decides
+func allocateColor(higher, lower Player, initialWhite bool) Pairing {
+	colorHigher, strengthHigher := higher.ColorPreference()
+	colorLower, strengthLower := lower.ColorPreference()
+
+	higherGets := func(color int) Pairing {
+		if color == ColorWhite {
+			return Pairing{White: higher.Id, Black: lower.Id}
+		}
+
+		return Pairing{White: lower.Id, Black: higher.Id}
+	}
+
+	switch {
+	// Both preferences can be granted
+	case strengthHigher != PreferenceNone && strengthLower != PreferenceNone && colorHigher != colorLower:
+		return higherGets(colorHigher)
+	case strengthHigher != PreferenceNone && strengthLower == PreferenceNone:
+		return higherGets(colorHigher)
+	case strengthHigher == PreferenceNone && strengthLower != PreferenceNone:
+		return higherGets(OppositeColor(colorLower))
+	case strengthHigher == PreferenceNone && strengthLower == PreferenceNone:
+		if initialWhite {
+			return higherGets(ColorWhite)
+		}
+		return higherGets(ColorBlack)
+	}
+
+	// The players want the same colour, grant the stronger preference
+	if strengthHigher != strengthLower {
+		if strengthHigher > strengthLower {
+			return higherGets(colorHigher)
+		}
+		return higherGets(OppositeColor(colorLower))
+	}
+
+	// Both absolute, grant the one with the wider colour difference
+	if strengthHigher == PreferenceAbsolute {
+		differenceHigher, differenceLower := abs(higher.ColorDifference()), abs(lower.ColorDifference())
+		if differenceHigher != differenceLower {
+			if differenceHigher > differenceLower {
+				return higherGets(colorHigher)
+			}
+			return higherGets(OppositeColor(colorLower))
+		}
+	}
+
+	// Alternate the colours to the most recent round in which they had different colours
+	for i, j := len(higher.Colors)-1, len(lower.Colors)-1; i >= 0 && j >= 0; i, j = i-1, j-1 {
+		if higher.Colors[i] != lower.Colors[j] {
+			return higherGets(OppositeColor(higher.Colors[i]))
+		}
+	}
+
+	// Grant the preference of the higher ranked player
+	return higherGets(colorHigher)
+}
+
+// AllocateColors turns the pairs into games with colours. The games are ordered by board, the board of a
+// game is decided by the best score and then the best rank of its players
+func AllocateColors(pairs [][]int64, players []Player) []Pairing {
+	byID := make(map[int64]Player)
+	rank := make(map[int64]int)
+	for i, player := range sortPlayers(players) {
+		byID[player.Id] = player
+		rank[player.Id] = i
+	}
+
+	ordered := make([][]int64, 0, len(pairs))
+	for _, pair := range pairs {
+		if rank[pair[1]] < rank[pair[0]] {
+			pair = []int64{pair[1], pair[0]}
+		}
+		ordered = append(ordered, pair)
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return rank[ordered[i][0]] < rank[ordered[j][0]]
+	})
+
+	pairings := make([]Pairing, 0, len(ordered))
+	for board, pair := range ordered {
+		pairings = append(pairings, allocateColor(byID[pair[0]], byID[pair[1]], board%2 == 0))
+	}
+
+	return pairings
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
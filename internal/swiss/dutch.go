@@ -0,0 +1,216 @@
+package swiss
+
+import "math"
+
+// penaltyLimit bounds the penalties of the games given to maxWeightMatching, which needs room for twice the
+// biggest weight in its dual variables
+const penaltyLimit = math.MaxInt64 / 8
+
+// DutchSystem pairs the players following the FIDE Dutch System (C.04.3). The brackets are paired from the
+// top score group down. Every bracket is paired with a maximum weight matching of the bracket together with
+// all the players below it, so the completion criterion always holds, and the weights of the games encode the
+// quality criteria in their order of importance after the forbidden games. The games inside the bracket are
+// kept and the players matched with lower players float down to the next bracket. Every bracket takes O(n³) time
+type DutchSystem struct{}
+
+// dutchBracket is a bracket of the Dutch System with the units of its quality criteria. Every unit is bigger
+// than the most that all the less important criteria can add up to in the bracket
+type dutchBracket struct {
+	players []Player // The moved down players followed by the residents
+	moved   int      // Number of moved down players
+	lowest  float64  // Score of the residents
+
+	floatUnit       int64
+	colorUnit       int64
+	scoreUnit       int64
+	downfloaterUnit int64
+	forbiddenUnit   int64
+}
+
+func (d DutchSystem) Pair(players []Player) ([][]int64, bool) {
+	players = sortPlayers(players)
+	playerOpponentMap := opponentMaps(players)
+
+	// C.04.3 A.1: the whole round must be pairable before any bracket is paired
+	if !pairable(playerIds(players), playerOpponentMap) {
+		return nil, false
+	}
+
+	groups := scoreGroups(players)
+	var result [][]int64
+	var moved []Player
+	for i, group := range groups {
+		var lower []Player
+		for _, g := range groups[i+1:] {
+			lower = append(lower, g...)
+		}
+
+		bracket, ok := newDutchBracket(moved, group)
+		if !ok {
+			return nil, false
+		}
+
+		pairs, downfloaters, ok := bracket.pair(lower, playerOpponentMap)
+		if !ok {
+			return nil, false
+		}
+		result = append(result, pairs...)
+		moved = downfloaters
+	}
+
+	return result, len(moved) == 0
+}
+
+// newDutchBracket returns the bracket of the moved down players and the residents. It fails when
+// the weights of the bracket don't fit in the range maxWeightMatching can work with
+func newDutchBracket(moved, residents []Player) (dutchBracket, bool) {
+	d := dutchBracket{moved: len(moved), lowest: residents[0].Score}
+	d.players = append(d.players, moved...)
+	d.players = append(d.players, residents...)
+
+	// The score above the residents that the moved down players could float down with
+	excess := int64(0)
+	for _, player := range moved {
+		excess += halfPoints(player.Score - d.lowest)
+	}
+
+	b := int64(len(d.players))
+	units := []struct {
+		unit   *int64
+		factor int64
+	}{
+		{&d.floatUnit, 2*b + 1}, // The positions add up to at most 2b for every one of the b players
+		{&d.colorUnit, 3*b + 1},
+		{&d.scoreUnit, b + 1},
+		{&d.downfloaterUnit, excess + 1},
+		{&d.forbiddenUnit, 2*b + 1}, // Every game of the bracket costs less than two downfloaters
+	}
+
+	previous := b
+	for _, u := range units {
+		if previous > penaltyLimit/u.factor {
+			return dutchBracket{}, false
+		}
+		*u.unit = previous * u.factor
+		previous = *u.unit
+	}
+
+	return d, d.forbiddenUnit <= penaltyLimit/2
+}
+
+// pair matches the bracket together with the lower players and returns the games inside the bracket
+// and the players of the bracket who float down, in the order of the bracket
+func (d dutchBracket) pair(lower []Player, playerOpponentMap map[int64]map[int64]struct{}) ([][]int64, []Player, bool) {
+	var all []Player
+	all = append(all, d.players...)
+	all = append(all, lower...)
+	b := len(d.players)
+
+	var edges []edge
+	var maxWeight int64
+	for i := range all {
+		for j := i + 1; j < len(all); j++ {
+			if _, has := playerOpponentMap[all[i].Id][all[j].Id]; has {
+				continue
+			}
+
+			// The games between lower players only have to exist, they are paired with the next brackets
+			var penalty int64
+			switch {
+			case j < b:
+				penalty = d.gamePenalty(i, j)
+			case i < b:
+				penalty = d.floatPenalty(i, j-b)
+			}
+
+			// Forbidden games are avoided before every quality criterion, also between the lower players
+			if forbidden(all[i], all[j]) {
+				penalty += d.forbiddenUnit
+			}
+			maxWeight = max(maxWeight, penalty)
+			edges = append(edges, edge{i: i, j: j, weight: penalty})
+		}
+	}
+
+	// Every matching has the same number of games, so the one with the biggest weight has the smallest penalty
+	for k := range edges {
+		edges[k].weight = maxWeight + 1 - edges[k].weight
+	}
+
+	mate := maxWeightMatching(len(all), edges, true)
+	var pairs [][]int64
+	var downfloaters []Player
+	for i, j := range mate {
+		switch {
+		case j == -1:
+			return nil, nil, false
+		case i >= b:
+		case j >= b:
+			downfloaters = append(downfloaters, d.players[i])
+		case i < j:
+			pairs = append(pairs, []int64{d.players[i].Id, d.players[j].Id})
+		}
+	}
+
+	return pairs, downfloaters, true
+}
+
+// gamePenalty returns the penalty of the game between the players at positions i < j of the bracket
+func (d dutchBracket) gamePenalty(i, j int) int64 {
+	higher, lower := d.players[i], d.players[j]
+	var penalty int64
+
+	// C.10 and C.11 players who don't get their colour preference
+	colorHigher, strengthHigher := higher.ColorPreference()
+	colorLower, strengthLower := lower.ColorPreference()
+	if strengthHigher != PreferenceNone && strengthLower != PreferenceNone && colorHigher == colorLower {
+		penalty += d.colorUnit
+		if min(strengthHigher, strengthLower) >= PreferenceStrong {
+			penalty += d.colorUnit
+		}
+	}
+
+	// C.15 and C.17 upfloaters who also floated up in the previous rounds
+	if higher.Score != lower.Score {
+		if lower.LastFloat(1) == FloatUp {
+			penalty += 2 * d.floatUnit
+		}
+		if lower.LastFloat(2) == FloatUp {
+			penalty += d.floatUnit
+		}
+	}
+
+	// The moved down players meet the highest residents, the residents meet S2 in the order of S1
+	if i < d.moved && j >= d.moved {
+		return penalty + int64(abs(j-d.moved-i))
+	}
+
+	half := (len(d.players) - d.moved) / 2
+	return penalty + int64(abs(j-i-half))
+}
+
+// floatPenalty returns the penalty of the player at position i of the bracket floating down to the
+// player at position k of the lower players
+func (d dutchBracket) floatPenalty(i, k int) int64 {
+	player := d.players[i]
+
+	// C.6 and C.7 the number and the score of the downfloaters
+	penalty := d.downfloaterUnit + halfPoints(player.Score-d.lowest)*d.scoreUnit
+
+	// C.14 and C.16 downfloaters who also floated down in the previous rounds
+	if player.LastFloat(1) == FloatDown {
+		penalty += 2 * d.floatUnit
+	}
+	if player.LastFloat(2) == FloatDown {
+		penalty += d.floatUnit
+	}
+
+	// The lowest player of the bracket floats down to the highest player below it
+	b := len(d.players)
+	return penalty + int64(b-1-i+min(k, b))
+}
+
+// halfPoints converts a score to a whole number of half points
+func halfPoints(score float64) int64 {
+	return int64(math.Round(score * 2))
+}
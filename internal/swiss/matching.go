@@ -0,0 +1,571 @@
+package swiss
+
+// edge is an edge of the graph given to maxWeightMatching, between the vertices i and j
+type edge struct {
+	i, j   int
+	weight int64
+}
+
+// maxWeightMatching computes a maximum weight matching of the graph with Edmonds' blossom algorithm in O(n³).
+// With maxCardinality only the matchings with the most edges are considered. It returns the vertex matched to
+// every vertex or -1 for the unmatched ones. Follows the primal-dual method of Galil, "Efficient algorithms for
+// finding maximum matching in graphs" (1986), with integer weights the dual variables stay integer
+func maxWeightMatching(n int, edges []edge, maxCardinality bool) []int {
+	mate := make([]int, n)
+	for i := range mate {
+		mate[i] = -1
+	}
+	if n == 0 || len(edges) == 0 {
+		return mate
+	}
+
+	var maxWeight int64
+	for _, e := range edges {
+		maxWeight = max(maxWeight, e.weight)
+	}
+
+	// Edge k has the endpoints 2k and 2k+1, neighbours holds the remote endpoints of the edges of every vertex
+	endpoint := make([]int, 2*len(edges))
+	neighbours := make([][]int, n)
+	for k, e := range edges {
+		endpoint[2*k], endpoint[2*k+1] = e.i, e.j
+		neighbours[e.i] = append(neighbours[e.i], 2*k+1)
+		neighbours[e.j] = append(neighbours[e.j], 2*k)
+	}
+
+	// mate holds the remote endpoint of the matched edge of every vertex while the algorithm runs
+	label := make([]int, 2*n) // 0 free, 1 S, 2 T, bit 4 marks the blossoms seen by scanBlossom
+	labelEnd := make([]int, 2*n)
+	inBlossom := make([]int, n)
+	blossomParent := make([]int, 2*n)
+	blossomChilds := make([][]int, 2*n)
+	blossomBase := make([]int, 2*n)
+	blossomEndps := make([][]int, 2*n)
+	bestEdge := make([]int, 2*n)
+	blossomBestEdges := make([][]int, 2*n)
+	dualVar := make([]int64, 2*n)
+	allowEdge := make([]bool, len(edges))
+	unusedBlossoms := make([]int, 0, n)
+	var queue []int
+
+	for v := 0; v < 2*n; v++ {
+		labelEnd[v], blossomParent[v], bestEdge[v] = -1, -1, -1
+		if v < n {
+			inBlossom[v], blossomBase[v], dualVar[v] = v, v, maxWeight
+		} else {
+			blossomBase[v] = -1
+			unusedBlossoms = append(unusedBlossoms, v)
+		}
+	}
+
+	slack := func(k int) int64 {
+		e := edges[k]
+		return dualVar[e.i] + dualVar[e.j] - 2*e.weight
+	}
+
+	var blossomLeaves func(b int) []int
+	blossomLeaves = func(b int) []int {
+		if b < n {
+			return []int{b}
+		}
+
+		var leaves []int
+		for _, t := range blossomChilds[b] {
+			leaves = append(leaves, blossomLeaves(t)...)
+		}
+		return leaves
+	}
+
+	indexOf := func(list []int, value int) int {
+		for i, v := range list {
+			if v == value {
+				return i
+			}
+		}
+		return -1
+	}
+
+	// assignLabel labels the top blossom of w with t, reached through the endpoint p
+	var assignLabel func(w, t, p int)
+	assignLabel = func(w, t, p int) {
+		b := inBlossom[w]
+		label[w], label[b] = t, t
+		labelEnd[w], labelEnd[b] = p, p
+		bestEdge[w], bestEdge[b] = -1, -1
+
+		if t == 1 {
+			queue = append(queue, blossomLeaves(b)...)
+			return
+		}
+
+		base := blossomBase[b]
+		assignLabel(endpoint[mate[base]], 1, mate[base]^1)
+	}
+
+	// scanBlossom traces back from v and w to find a new blossom or an augmenting path, returning the base of the blossom or -1
+	scanBlossom := func(v, w int) int {
+		var path []int
+		base := -1
+		for v != -1 || w != -1 {
+			b := inBlossom[v]
+			if label[b]&4 != 0 {
+				base = blossomBase[b]
+				break
+			}
+
+			path = append(path, b)
+			label[b] = 5
+			if labelEnd[b] == -1 {
+				v = -1
+			} else {
+				v = endpoint[labelEnd[b]]
+				b = inBlossom[v]
+				v = endpoint[labelEnd[b]]
+			}
+
+			if w != -1 {
+				v, w = w, v
+			}
+		}
+
+		for _, b := range path {
+			label[b] = 1
+		}
+		return base
+	}
+
+	// addBlossom creates a new blossom with the given base, through the S-vertices of edge k
+	addBlossom := func(base, k int) {
+		v, w := edges[k].i, edges[k].j
+		bb, bv, bw := inBlossom[base], inBlossom[v], inBlossom[w]
+
+		b := unusedBlossoms[len(unusedBlossoms)-1]
+		unusedBlossoms = unusedBlossoms[:len(unusedBlossoms)-1]
+		blossomBase[b] = base
+		blossomParent[b] = -1
+		blossomParent[bb] = b
+
+		var path, endps []int
+		for bv != bb {
+			blossomParent[bv] = b
+			path = append(path, bv)
+			endps = append(endps, labelEnd[bv])
+			v = endpoint[labelEnd[bv]]
+			bv = inBlossom[v]
+		}
+		path = append(path, bb)
+		for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+			path[i], path[j] = path[j], path[i]
+		}
+		for i, j := 0, len(endps)-1; i < j; i, j = i+1, j-1 {
+			endps[i], endps[j] = endps[j], endps[i]
+		}
+		endps = append(endps, 2*k)
+
+		for bw != bb {
+			blossomParent[bw] = b
+			path = append(path, bw)
+			endps = append(endps, labelEnd[bw]^1)
+			w = endpoint[labelEnd[bw]]
+			bw = inBlossom[w]
+		}
+
+		blossomChilds[b], blossomEndps[b] = path, endps
+		label[b] = 1
+		labelEnd[b] = labelEnd[bb]
+		dualVar[b] = 0
+
+		for _, leaf := range blossomLeaves(b) {
+			if label[inBlossom[leaf]] == 2 {
+				queue = append(queue, leaf)
+			}
+			inBlossom[leaf] = b
+		}
+
+		// Keep for every neighbouring S-blossom the edge with the least slack
+		bestEdgeTo := make([]int, 2*n)
+		for i := range bestEdgeTo {
+			bestEdgeTo[i] = -1
+		}
+
+		for _, child := range path {
+			var lists [][]int
+			if blossomBestEdges[child] == nil {
+				for _, leaf := range blossomLeaves(child) {
+					list := make([]int, 0, len(neighbours[leaf]))
+					for _, p := range neighbours[leaf] {
+						list = append(list, p/2)
+					}
+					lists = append(lists, list)
+				}
+			} else {
+				lists = [][]int{blossomBestEdges[child]}
+			}
+
+			for _, list := range lists {
+				for _, k := range list {
+					j := edges[k].j
+					if inBlossom[j] == b {
+						j = edges[k].i
+					}
+
+					bj := inBlossom[j]
+					if bj != b && label[bj] == 1 && (bestEdgeTo[bj] == -1 || slack(k) < slack(bestEdgeTo[bj])) {
+						bestEdgeTo[bj] = k
+					}
+				}
+			}
+
+			blossomBestEdges[child] = nil
+			bestEdge[child] = -1
+		}
+
+		blossomBestEdges[b] = nil
+		for _, k := range bestEdgeTo {
+			if k != -1 {
+				blossomBestEdges[b] = append(blossomBestEdges[b], k)
+			}
+		}
+
+		bestEdge[b] = -1
+		for _, k := range blossomBestEdges[b] {
+			if bestEdge[b] == -1 || slack(k) < slack(bestEdge[b]) {
+				bestEdge[b] = k
+			}
+		}
+	}
+
+	// expandBlossom turns the sub-blossoms of b into top blossoms, relabelling them when it happens during a stage
+	var expandBlossom func(b int, endStage bool)
+	expandBlossom = func(b int, endStage bool) {
+		for _, s := range blossomChilds[b] {
+			blossomParent[s] = -1
+			switch {
+			case s < n:
+				inBlossom[s] = s
+			case endStage && dualVar[s] == 0:
+				expandBlossom(s, endStage)
+			default:
+				for _, leaf := range blossomLeaves(s) {
+					inBlossom[leaf] = s
+				}
+			}
+		}
+
+		if !endStage && label[b] == 2 {
+			childs, endps := blossomChilds[b], blossomEndps[b]
+			at := func(j int) int {
+				return (j%len(childs) + len(childs)) % len(childs)
+			}
+
+			entryChild := inBlossom[endpoint[labelEnd[b]^1]]
+			j := indexOf(childs, entryChild)
+			jStep, endpTrick := -1, 1
+			if j&1 != 0 {
+				j -= len(childs)
+				jStep, endpTrick = 1, 0
+			}
+
+			// Relabel the T-sub-blossoms on the even path from the entry child to the base
+			p := labelEnd[b]
+			for j != 0 {
+				label[endpoint[p^1]] = 0
+				label[endpoint[endps[at(j-endpTrick)]^endpTrick^1]] = 0
+				assignLabel(endpoint[p^1], 2, p)
+				allowEdge[endps[at(j-endpTrick)]/2] = true
+				j += jStep
+				p = endps[at(j-endpTrick)] ^ endpTrick
+				allowEdge[p/2] = true
+				j += jStep
+			}
+
+			bv := childs[at(j)]
+			label[endpoint[p^1]], label[bv] = 2, 2
+			labelEnd[endpoint[p^1]], labelEnd[bv] = p, p
+			bestEdge[bv] = -1
+			j += jStep
+
+			// The sub-blossoms on the odd path lose their labels unless one of their vertices is reachable
+			for childs[at(j)] != entryChild {
+				bv = childs[at(j)]
+				if label[bv] == 1 {
+					j += jStep
+					continue
+				}
+
+				reached := -1
+				for _, leaf := range blossomLeaves(bv) {
+					if label[leaf] != 0 {
+						reached = leaf
+						break
+					}
+				}
+
+				if reached != -1 {
+					label[reached] = 0
+					label[endpoint[mate[blossomBase[bv]]]] = 0
+					assignLabel(reached, 2, labelEnd[reached])
+				}
+				j += jStep
+			}
+		}
+
+		label[b], labelEnd[b] = -1, -1
+		blossomChilds[b], blossomEndps[b] = nil, nil
+		blossomBase[b] = -1
+		blossomBestEdges[b] = nil
+		bestEdge[b] = -1
+		unusedBlossoms = append(unusedBlossoms, b)
+	}
+
+	// augmentBlossom swaps the matched and unmatched edges on the path from v to the base of b, making v the new base
+	var augmentBlossom func(b, v int)
+	augmentBlossom = func(b, v int) {
+		t := v
+		for blossomParent[t] != b {
+			t = blossomParent[t]
+		}
+		if t >= n {
+			augmentBlossom(t, v)
+		}
+
+		childs, endps := blossomChilds[b], blossomEndps[b]
+		at := func(j int) int {
+			return (j%len(childs) + len(childs)) % len(childs)
+		}
+
+		i := indexOf(childs, t)
+		j := i
+		jStep, endpTrick := -1, 1
+		if i&1 != 0 {
+			j -= len(childs)
+			jStep, endpTrick = 1, 0
+		}
+
+		for j != 0 {
+			j += jStep
+			t = childs[at(j)]
+			p := endps[at(j-endpTrick)] ^ endpTrick
+			if t >= n {
+				augmentBlossom(t, endpoint[p])
+			}
+
+			j += jStep
+			t = childs[at(j)]
+			if t >= n {
+				augmentBlossom(t, endpoint[p^1])
+			}
+
+			mate[endpoint[p]] = p ^ 1
+			mate[endpoint[p^1]] = p
+		}
+
+		blossomChilds[b] = append(append([]int{}, childs[i:]...), childs[:i]...)
+		blossomEndps[b] = append(append([]int{}, endps[i:]...), endps[:i]...)
+		blossomBase[b] = blossomBase[blossomChilds[b][0]]
+	}
+
+	// augmentMatching swaps the matched and unmatched edges along the augmenting path through edge k
+	augmentMatching := func(k int) {
+		for _, start := range [][2]int{{edges[k].i, 2*k + 1}, {edges[k].j, 2 * k}} {
+			s, p := start[0], start[1]
+			for {
+				bs := inBlossom[s]
+				if bs >= n {
+					augmentBlossom(bs, s)
+				}
+				mate[s] = p
+
+				if labelEnd[bs] == -1 {
+					break
+				}
+
+				t := endpoint[labelEnd[bs]]
+				bt := inBlossom[t]
+				s = endpoint[labelEnd[bt]]
+				j := endpoint[labelEnd[bt]^1]
+				if bt >= n {
+					augmentBlossom(bt, j)
+				}
+				mate[j] = labelEnd[bt]
+				p = labelEnd[bt] ^ 1
+			}
+		}
+	}
+
+	// Every stage finds one augmenting path, there are at most n/2 of them
+	for stage := 0; stage < n; stage++ {
+		for i := range label {
+			label[i], bestEdge[i] = 0, -1
+		}
+		for i := n; i < 2*n; i++ {
+			blossomBestEdges[i] = nil
+		}
+		for i := range allowEdge {
+			allowEdge[i] = false
+		}
+		queue = queue[:0]
+
+		for v := 0; v < n; v++ {
+			if mate[v] == -1 && label[inBlossom[v]] == 0 {
+				assignLabel(v, 1, -1)
+			}
+		}
+
+		augmented := false
+		for {
+			for len(queue) > 0 && !augmented {
+				v := queue[len(queue)-1]
+				queue = queue[:len(queue)-1]
+
+				for _, p := range neighbours[v] {
+					k := p / 2
+					w := endpoint[p]
+					if inBlossom[v] == inBlossom[w] {
+						continue
+					}
+
+					var kSlack int64
+					if !allowEdge[k] {
+						kSlack = slack(k)
+						if kSlack <= 0 {
+							allowEdge[k] = true
+						}
+					}
+
+					switch {
+					case allowEdge[k] && label[inBlossom[w]] == 0:
+						assignLabel(w, 2, p^1)
+					case allowEdge[k] && label[inBlossom[w]] == 1:
+						base := scanBlossom(v, w)
+						if base >= 0 {
+							addBlossom(base, k)
+						} else {
+							augmentMatching(k)
+							augmented = true
+						}
+					case allowEdge[k] && label[w] == 0:
+						label[w] = 2
+						labelEnd[w] = p ^ 1
+					case !allowEdge[k] && label[inBlossom[w]] == 1:
+						b := inBlossom[v]
+						if bestEdge[b] == -1 || kSlack < slack(bestEdge[b]) {
+							bestEdge[b] = k
+						}
+					case !allowEdge[k] && label[w] == 0:
+						if bestEdge[w] == -1 || kSlack < slack(bestEdge[w]) {
+							bestEdge[w] = k
+						}
+					}
+
+					if augmented {
+						break
+					}
+				}
+			}
+
+			if augmented {
+				break
+			}
+
+			// No augmenting path with the allowed edges, change the dual variables to allow more edges
+			deltaType, delta, deltaEdge, deltaBlossom := -1, int64(0), -1, -1
+			if !maxCardinality {
+				deltaType = 1
+				delta = dualVar[0]
+				for v := 1; v < n; v++ {
+					delta = min(delta, dualVar[v])
+				}
+			}
+
+			for v := 0; v < n; v++ {
+				if label[inBlossom[v]] == 0 && bestEdge[v] != -1 {
+					if d := slack(bestEdge[v]); deltaType == -1 || d < delta {
+						delta, deltaType, deltaEdge = d, 2, bestEdge[v]
+					}
+				}
+			}
+
+			for b := 0; b < 2*n; b++ {
+				if blossomParent[b] == -1 && label[b] == 1 && bestEdge[b] != -1 {
+					if d := slack(bestEdge[b]) / 2; deltaType == -1 || d < delta {
+						delta, deltaType, deltaEdge = d, 3, bestEdge[b]
+					}
+				}
+			}
+
+			for b := n; b < 2*n; b++ {
+				if blossomBase[b] >= 0 && blossomParent[b] == -1 && label[b] == 2 && (deltaType == -1 || dualVar[b] < delta) {
+					delta, deltaType, deltaBlossom = dualVar[b], 4, b
+				}
+			}
+
+			if deltaType == -1 {
+				// Only possible with maxCardinality, the matching has the most edges and the best weight
+				deltaType = 1
+				delta = dualVar[0]
+				for v := 1; v < n; v++ {
+					delta = min(delta, dualVar[v])
+				}
+				delta = max(0, delta)
+			}
+
+			for v := 0; v < n; v++ {
+				switch label[inBlossom[v]] {
+				case 1:
+					dualVar[v] -= delta
+				case 2:
+					dualVar[v] += delta
+				}
+			}
+
+			for b := n; b < 2*n; b++ {
+				if blossomBase[b] >= 0 && blossomParent[b] == -1 {
+					switch label[b] {
+					case 1:
+						dualVar[b] += delta
+					case 2:
+						dualVar[b] -= delta
+					}
+				}
+			}
+
+			if deltaType == 1 {
+				break
+			}
+
+			switch deltaType {
+			case 2:
+				allowEdge[deltaEdge] = true
+				i := edges[deltaEdge].i
+				if label[inBlossom[i]] == 0 {
+					i = edges[deltaEdge].j
+				}
+				queue = append(queue, i)
+			case 3:
+				allowEdge[deltaEdge] = true
+				queue = append(queue, edges[deltaEdge].i)
+			case 4:
+				expandBlossom(deltaBlossom, false)
+			}
+		}
+
+		if !augmented {
+			break
+		}
+
+		for b := n; b < 2*n; b++ {
+			if blossomParent[b] == -1 && blossomBase[b] >= 0 && label[b] == 1 && dualVar[b] == 0 {
+				expandBlossom(b, true)
+			}
+		}
+	}
+
+	for v := range mate {
+		if mate[v] >= 0 {
+			mate[v] = endpoint[mate[v]]
+		}
+	}
+
+	return mate
+}
@@ -0,0 +1,64 @@
+package swiss
+
+import (
+	"slices"
+	"testing"
+)
+
+// The cases of the test suite of mwmatching.py, which maxWeightMatching is ported from
+func TestMaxWeightMatching(t *testing.T) {
+	tests := []struct {
+		name           string
+		n              int
+		edges          []edge
+		maxCardinality bool
+		want           []int
+	}{
+		{"empty", 0, nil, false, []int{}},
+		{"single edge", 2, []edge{{0, 1, 1}}, false, []int{1, 0}},
+		{"two edges", 4, []edge{{1, 2, 10}, {2, 3, 11}}, false, []int{-1, -1, 3, 2}},
+		{"three edges", 5, []edge{{1, 2, 5}, {2, 3, 11}, {3, 4, 5}}, false, []int{-1, -1, 3, 2, -1}},
+		{"maximum cardinality", 5, []edge{{1, 2, 5}, {2, 3, 11}, {3, 4, 5}}, true, []int{-1, 2, 1, 4, 3}},
+		{"negative weights", 5, []edge{{1, 2, 2}, {1, 3, -2}, {2, 3, 1}, {2, 4, -1}, {3, 4, -6}}, false, []int{-1, 2, 1, -1, -1}},
+		{"negative weights with maximum cardinality", 5, []edge{{1, 2, 2}, {1, 3, -2}, {2, 3, 1}, {2, 4, -1}, {3, 4, -6}}, true,
+			[]int{-1, 3, 4, 1, 2}},
+		{"S-blossom", 5, []edge{{1, 2, 8}, {1, 3, 9}, {2, 3, 10}, {3, 4, 7}}, false, []int{-1, 2, 1, 4, 3}},
+		{"S-blossom with augmentation", 7, []edge{{1, 2, 8}, {1, 3, 9}, {2, 3, 10}, {3, 4, 7}, {1, 6, 5}, {4, 5, 6}}, false,
+			[]int{-1, 6, 3, 2, 5, 4, 1}},
+		{"T-blossom", 7, []edge{{1, 2, 9}, {1, 3, 8}, {2, 3, 10}, {1, 4, 5}, {4, 5, 4}, {1, 6, 3}}, false, []int{-1, 6, 3, 2, 5, 4, 1}},
+		{"T-blossom with a heavier edge", 7, []edge{{1, 2, 9}, {1, 3, 8}, {2, 3, 10}, {1, 4, 5}, {4, 5, 3}, {1, 6, 4}}, false,
+			[]int{-1, 6, 3, 2, 5, 4, 1}},
+		{"T-blossom with another base", 7, []edge{{1, 2, 9}, {1, 3, 8}, {2, 3, 10}, {1, 4, 5}, {4, 5, 3}, {3, 6, 4}}, false,
+			[]int{-1, 2, 1, 6, 5, 4, 3}},
+		{"nested S-blossom", 7, []edge{{1, 2, 9}, {1, 3, 9}, {2, 3, 10}, {2, 4, 8}, {3, 5, 8}, {4, 5, 10}, {5, 6, 6}}, false,
+			[]int{-1, 3, 4, 1, 2, 6, 5}},
+		{"nested S-blossom relabelled", 9, []edge{{1, 2, 10}, {1, 7, 10}, {2, 3, 12}, {3, 4, 20}, {3, 5, 20}, {4, 5, 25}, {5, 6, 10},
+			{6, 7, 10}, {7, 8, 8}}, false, []int{-1, 2, 1, 4, 3, 6, 5, 8, 7}},
+		{"nested S-blossom expanded", 9, []edge{{1, 2, 8}, {1, 3, 8}, {2, 3, 10}, {2, 4, 12}, {3, 5, 12}, {4, 5, 14}, {4, 6, 12},
+			{5, 7, 12}, {6, 7, 14}, {7, 8, 12}}, false, []int{-1, 2, 1, 5, 6, 3, 4, 8, 7}},
+		{"S-blossom relabelled as T and expanded", 9, []edge{{1, 2, 23}, {1, 5, 22}, {1, 6, 15}, {2, 3, 25}, {3, 4, 22}, {4, 5, 25},
+			{4, 8, 14}, {5, 7, 13}}, false, []int{-1, 6, 3, 2, 8, 7, 1, 5, 4}},
+		{"nested S-blossom relabelled as T and expanded", 9, []edge{{1, 2, 19}, {1, 3, 20}, {1, 8, 8}, {2, 3, 25}, {2, 4, 18},
+			{3, 5, 18}, {4, 5, 13}, {4, 7, 7}, {5, 6, 7}}, false, []int{-1, 8, 3, 2, 7, 6, 5, 4, 1}},
+		{"nasty T-blossom expanded", 11, []edge{{1, 2, 45}, {1, 5, 45}, {2, 3, 50}, {3, 4, 45}, {4, 5, 50}, {1, 6, 30}, {3, 9, 35},
+			{4, 8, 35}, {5, 7, 26}, {9, 10, 5}}, false, []int{-1, 6, 3, 2, 8, 7, 1, 5, 4, 10, 9}},
+		{"nasty T-blossom expanded again", 11, []edge{{1, 2, 45}, {1, 5, 45}, {2, 3, 50}, {3, 4, 45}, {4, 5, 50}, {1, 6, 30},
+			{3, 9, 35}, {4, 8, 26}, {5, 7, 40}, {9, 10, 5}}, false, []int{-1, 6, 3, 2, 8, 7, 1, 5, 4, 10, 9}},
+		{"T-blossom expanded with the least slack", 11, []edge{{1, 2, 45}, {1, 5, 45}, {2, 3, 50}, {3, 4, 45}, {4, 5, 50},
+			{1, 6, 30}, {3, 9, 35}, {4, 8, 28}, {5, 7, 26}, {9, 10, 5}}, false, []int{-1, 6, 3, 2, 8, 7, 1, 5, 4, 10, 9}},
+		{"nested nasty T-blossom expanded", 13, []edge{{1, 2, 45}, {1, 7, 45}, {2, 3, 50}, {3, 4, 45}, {4, 5, 95}, {4, 6, 94},
+			{5, 6, 94}, {6, 7, 50}, {1, 8, 30}, {3, 11, 35}, {5, 9, 36}, {7, 10, 26}, {11, 12, 5}}, false,
+			[]int{-1, 8, 3, 2, 6, 9, 4, 10, 1, 5, 7, 12, 11}},
+		{"nested blossom relabelled and expanded", 11, []edge{{1, 2, 40}, {1, 3, 40}, {2, 3, 60}, {2, 4, 55}, {3, 5, 55}, {4, 5, 50},
+			{1, 8, 15}, {5, 7, 30}, {7, 6, 10}, {8, 10, 10}, {4, 9, 30}}, false, []int{-1, 2, 1, 5, 9, 3, 7, 6, 10, 4, 8}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := maxWeightMatching(test.n, test.edges, test.maxCardinality)
+			if !slices.Equal(got, test.want) {
+				t.Errorf("maxWeightMatching() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
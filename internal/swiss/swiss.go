@@ -1,9 +1,61 @@
 package swiss
 
+import (
+	"math"
+	"sort"
+)
+
 type Player struct {
-	Id       int64
-	Score    float64
-	Opponent map[int64]struct{} // Opponents encountered before
+	Id        int64
+	Score     float64
+	Rating    int
+	Opponent  map[int64]struct{} // Opponents encountered before
+	Forbidden map[int64]struct{} // Players who shouldn't be paired with the player, like teammates or relatives
+	Floats    []int              // Float received in every previous round, oldest first
+	Colors    []int              // Colour played with in every previous game, oldest first
+	HadBye    bool               // Received a pairing-allocated bye before
+}
+
+// PairingSystem pairs an even number of players for the next round of a tournament
+type PairingSystem interface {
+	Pair(players []Player) ([][]int64, bool)
+}
+
+const (
+	SystemScoreGroups = iota + 1
+	SystemDutch
+)
+
+const (
+	FloatNone = iota
+	FloatDown
+	FloatUp
+)
+
+// NewPairingSystem returns the pairing system stored for a tournament
+func NewPairingSystem(system int) PairingSystem {
+	switch system {
+	case SystemDutch:
+		return DutchSystem{}
+	default:
+		return ScoreGroupSystem{}
+	}
+}
+
+// ScoreGroupSystem pairs the players top half against bottom half inside their score groups
+type ScoreGroupSystem struct{}
+
+func (ScoreGroupSystem) Pair(players []Player) ([][]int64, bool) {
+	return pairBracket(sortPlayers(players), opponentMaps(players))
+}
+
+// LastFloat returns the float the player received the given number of rounds ago
+func (p Player) LastFloat(roundsAgo int) int {
+	if roundsAgo < 1 || roundsAgo > len(p.Floats) {
+		return FloatNone
+	}
+
+	return p.Floats[len(p.Floats)-roundsAgo]
 }
 
 func GetIndexOfPlayer(players []Player, id int) int {
@@ -16,68 +68,276 @@ func GetIndexOfPlayer(players []Player, id int) int {
 	return -1
 }
 
-// pickTablePlayer calculates the arrangement of players in a Swiss Tournament
-func pickTablePlayer(players []int64, playerOpponentMap map[int64]map[int64]struct{}) ([]int64, bool) {
-	if len(players) < 2 {
-		return players, true
-	}
-	whitePlayer := players[0]
-	opponentMap, _ := playerOpponentMap[whitePlayer]
-	for i := range players {
-		if i != 0 {
-			// Check if already played against
-			if _, has := opponentMap[players[i]]; !has {
-				// Select
-				res := make([]int64, 2)
-				res[0] = whitePlayer
-				res[1] = players[i]
-
-				// Assemble the remaining sorted data
-				var nextRound []int64
-				nextRound = append(nextRound, players[1:i]...)
-				nextRound = append(nextRound, players[i+1:]...)
-				pick, ok := pickTablePlayer(nextRound, playerOpponentMap) // Proceed to the next round of sorting
-				if ok {
-					return append(res, pick...), true // Success, result floats up
-				}
-			}
+// sortPlayers orders the players by score and then by rating, keeping the given order for players equal on both
+func sortPlayers(players []Player) []Player {
+	sorted := make([]Player, len(players))
+	copy(sorted, players)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Score != sorted[j].Score {
+			return sorted[i].Score > sorted[j].Score
 		}
-	}
-	return nil, false // Failure, recalculate in the upper level
-}
 
-func CreateSwissRound(players []Player) (playerBattleList [][]int64, emptyPlayer int64, ok bool) {
-	ok = true
+		return sorted[i].Rating > sorted[j].Rating
+	})
 
-	// Determine the bye player
-	total := len(players)
-	if total%2 != 0 {
-		emptyPlayer = players[total-1].Id
-		players = players[:total]
+	return sorted
+}
+
+// scoreGroups splits the sorted players into groups of players with the same score
+func scoreGroups(players []Player) [][]Player {
+	var groups [][]Player
+	for i, player := range players {
+		if i == 0 || players[i-1].Score != player.Score {
+			groups = append(groups, []Player{})
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], player)
 	}
 
-	// Convert data structure
-	var playerIds []int64
-	var playerOpponentMap = make(map[int64]map[int64]struct{})
+	return groups
+}
+
+func opponentMaps(players []Player) map[int64]map[int64]struct{} {
+	playerOpponentMap := make(map[int64]map[int64]struct{})
 	for _, v := range players {
-		playerIds = append(playerIds, v.Id)
 		if _, has := playerOpponentMap[v.Id]; !has {
 			playerOpponentMap[v.Id] = v.Opponent
 		}
 	}
 
-	// Calculate the match order
-	playerList, ok := pickTablePlayer(playerIds, playerOpponentMap)
+	return playerOpponentMap
+}
+
+func playerIds(players []Player) []int64 {
+	ids := make([]int64, 0, len(players))
+	for _, player := range players {
+		ids = append(ids, player.Id)
+	}
+
+	return ids
+}
+
+// pairable reports whether all the players can be paired with opponents they haven't met yet
+func pairable(ids []int64, playerOpponentMap map[int64]map[int64]struct{}) bool {
+	if len(ids)%2 != 0 {
+		return false
+	}
+
+	var edges []edge
+	for i := range ids {
+		for j := i + 1; j < len(ids); j++ {
+			if _, has := playerOpponentMap[ids[i]][ids[j]]; !has {
+				edges = append(edges, edge{i: i, j: j, weight: 1})
+			}
+		}
+	}
+
+	for _, matched := range maxWeightMatching(len(ids), edges, true) {
+		if matched == -1 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// forbidden reports whether the players shouldn't meet
+func forbidden(a, b Player) bool {
+	_, has := a.Forbidden[b.Id]
+	_, hasB := b.Forbidden[a.Id]
+	return has || hasB
+}
+
+// penaltyUnits are the units of the criteria of pairingPenalty. Every unit is bigger than the most
+// that all the less important criteria can add up to in the bracket
+type penaltyUnits struct {
+	float     int64
+	color     int64
+	score     int64
+	forbidden int64 // A forbidden game costs more than all the other criteria of the round together
+}
+
+// newPenaltyUnits returns the units of the sorted bracket. It fails when the penalties of the bracket
+// don't fit in the range maxWeightMatching can work with
+func newPenaltyUnits(bracket []Player) (penaltyUnits, bool) {
+	var u penaltyUnits
+	if len(bracket) == 0 {
+		return u, true
+	}
+
+	n := int64(len(bracket))
+	maxDifference := halfPoints(bracket[0].Score - bracket[n-1].Score)
+	if maxDifference > math.MaxInt32 {
+		return penaltyUnits{}, false
+	}
+
+	units := []struct {
+		unit   *int64
+		factor int64
+	}{
+		{&u.float, n*n + 1},
+		{&u.color, n + 1},
+		{&u.score, n + 1},
+		{&u.forbidden, maxDifference*maxDifference + 1}, // Above the score difference of any game
+		{&u.forbidden, n/2 + 1},                         // Above the score differences of all the games
+	}
+
+	previous := int64(1)
+	for _, unit := range units {
+		if previous > penaltyLimit/unit.factor {
+			return penaltyUnits{}, false
+		}
+		*unit.unit = previous * unit.factor
+		previous = *unit.unit
+	}
+
+	// The other criteria of a game add up to less than the forbidden unit
+	return u, u.forbidden <= penaltyLimit/2
+}
+
+// pairingPenalty returns how far the game between the players at positions i < j of the sorted bracket is
+// from the ideal pairing. The criteria in order of importance are forbidden games, the score difference,
+// the colour preferences, repeated floats and the positions inside the score groups (top half against bottom
+// half, the lowest player of a group floats down to the highest of the next one). Every criterion gets its own
+// range of values, so that no amount of a less important one outweighs a more important one
+func pairingPenalty(bracket []Player, units penaltyUnits, positions, sizes []int, i, j int) int64 {
+	higher, lower := bracket[i], bracket[j]
+	difference := halfPoints(higher.Score - lower.Score)
+	penalty := difference * difference * units.score
+
+	// The matching breaks as few forbidden pairings as the round allows
+	if forbidden(higher, lower) {
+		penalty += units.forbidden
+	}
+
+	colorHigher, strengthHigher := higher.ColorPreference()
+	colorLower, strengthLower := lower.ColorPreference()
+	if strengthHigher != PreferenceNone && strengthLower != PreferenceNone && colorHigher == colorLower {
+		penalty += units.color
+		if min(strengthHigher, strengthLower) >= PreferenceStrong {
+			penalty += units.color
+		}
+	}
+
+	if difference == 0 {
+		// The players of the top half should meet the players of the bottom half in the same order
+		half := sizes[i] / 2
+		return penalty + int64(abs(positions[j]-positions[i]-half))
+	}
+
+	if higher.LastFloat(1) == FloatDown {
+		penalty += units.float
+	}
+	if lower.LastFloat(1) == FloatUp {
+		penalty += units.float
+	}
+
+	return penalty + int64(sizes[i]-1-positions[i]+positions[j])
+}
+
+// pairBracket pairs all the players of the sorted bracket with a minimum weight perfect matching of the
+// games they can still play, which takes polynomial time however few pairings remain
+func pairBracket(bracket []Player, playerOpponentMap map[int64]map[int64]struct{}) ([][]int64, bool) {
+	if len(bracket)%2 != 0 {
+		return nil, false
+	}
+
+	// The position of every player inside its score group and the size of the group
+	positions, sizes := make([]int, len(bracket)), make([]int, len(bracket))
+	start := 0
+	for _, group := range scoreGroups(bracket) {
+		for k := range group {
+			positions[start+k], sizes[start+k] = k, len(group)
+		}
+		start += len(group)
+	}
+
+	units, ok := newPenaltyUnits(bracket)
 	if !ok {
-		return playerBattleList, emptyPlayer, ok
+		return nil, false
+	}
+
+	var edges []edge
+	var maxPenalty int64
+	for i := range bracket {
+		for j := i + 1; j < len(bracket); j++ {
+			if _, has := playerOpponentMap[bracket[i].Id][bracket[j].Id]; has {
+				continue
+			}
+
+			penalty := pairingPenalty(bracket, units, positions, sizes, i, j)
+			maxPenalty = max(maxPenalty, penalty)
+			edges = append(edges, edge{i: i, j: j, weight: penalty})
+		}
 	}
 
-	// Convert to a two-dimensional array
-	for i := 0; i < len(playerList)/2; i++ {
-		playerBattleList = append(playerBattleList, []int64{
-			playerList[i*2],
-			playerList[i*2+1],
-		})
+	// The matching maximizes the weight, so the games with the smallest penalty get the biggest weight
+	for k := range edges {
+		edges[k].weight = maxPenalty + 1 - edges[k].weight
 	}
-	return
+
+	mate := maxWeightMatching(len(bracket), edges, true)
+	pairs := make([][]int64, 0, len(bracket)/2)
+	for i, j := range mate {
+		if j == -1 {
+			return nil, false
+		}
+
+		if i < j {
+			pairs = append(pairs, []int64{bracket[i].Id, bracket[j].Id})
+		}
+	}
+
+	return pairs, true
+}
+
+// CreateSwissRound pairs the players for the next round. Forbidden pairings are only allowed when the round can't be
+// paired without them and then the pairing systems break as few of them as possible
+func CreateSwissRound(players []Player, system PairingSystem) (playerBattleList []Pairing, emptyPlayer int64, ok bool) {
+	return pairPlayers(sortPlayers(players), system)
+}
+
+// pairPlayers pairs the sorted players, giving the bye first when their number is odd
+func pairPlayers(players []Player, system PairingSystem) (playerBattleList []Pairing, emptyPlayer int64, ok bool) {
+	if len(players)%2 == 0 {
+		playerBattleList, ok = pairRound(players, system)
+		return
+	}
+
+	// The bye goes to the lowest ranked player who hasn't had one yet and whose absence still
+	// lets the others be paired. Only when nobody like that exists a second bye is given
+	for _, allowRepeat := range []bool{false, true} {
+		for i := len(players) - 1; i >= 0; i-- {
+			if players[i].HadBye != allowRepeat {
+				continue
+			}
+
+			var rest []Player
+			rest = append(rest, players[:i]...)
+			rest = append(rest, players[i+1:]...)
+			playerBattleList, ok = pairRound(rest, system)
+			if ok {
+				return playerBattleList, players[i].Id, true
+			}
+		}
+	}
+
+	return nil, 0, false
+}
+
+// pairRound pairs an even number of players and allocates the colours
+func pairRound(players []Player, system PairingSystem) ([]Pairing, bool) {
+	if len(players) == 0 {
+		return nil, true
+	}
+
+	// Calculate the match order, avoiding players with the same absolute colour preference when possible
+	pairs, ok := system.Pair(withColorConflicts(players))
+	if !ok {
+		pairs, ok = system.Pair(players)
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return AllocateColors(pairs, players), true
 }
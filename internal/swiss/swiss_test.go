@@ -0,0 +1,273 @@
+package swiss
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// syntheticField plays the given number of rounds of a tournament with random results and returns its players
+func syntheticField(b *testing.B, size, rounds int, system PairingSystem) []Player {
+	random := rand.New(rand.NewSource(1))
+	players := make([]Player, size)
+	for i := range players {
+		players[i] = Player{Id: int64(i + 1), Rating: 2800 - i, Opponent: make(map[int64]struct{})}
+	}
+
+	index := make(map[int64]int)
+	for i, player := range players {
+		index[player.Id] = i
+	}
+
+	for round := 1; round <= rounds; round++ {
+		pairings, emptyPlayer, ok := CreateSwissRound(players, system)
+		if !ok {
+			b.Fatalf("unable to pair round %d of %d players", round, size)
+		}
+
+		for _, pairing := range pairings {
+			white, black := &players[index[pairing.White]], &players[index[pairing.Black]]
+			white.Opponent[black.Id] = struct{}{}
+			black.Opponent[white.Id] = struct{}{}
+			white.Colors = append(white.Colors, ColorWhite)
+			black.Colors = append(black.Colors, ColorBlack)
+
+			switch random.Intn(3) {
+			case 0:
+				white.Score++
+			case 1:
+				black.Score++
+			default:
+				white.Score += 0.5
+				black.Score += 0.5
+			}
+		}
+
+		if emptyPlayer != 0 {
+			players[index[emptyPlayer]].Score++
+			players[index[emptyPlayer]].HadBye = true
+		}
+	}
+
+	return players
+}
+
+// BenchmarkPair measures the pairing of the last round of large synthetic tournaments, when few legal pairings remain
+func BenchmarkPair(b *testing.B) {
+	systems := []struct {
+		name   string
+		system PairingSystem
+	}{
+		{"score_groups", NewPairingSystem(SystemScoreGroups)},
+		{"dutch", NewPairingSystem(SystemDutch)},
+	}
+
+	for _, s := range systems {
+		for _, size := range []int{100, 200, 400} {
+			b.Run(fmt.Sprintf("%s/%d", s.name, size), func(b *testing.B) {
+				players := syntheticField(b, size, 8, s.system)
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					if _, _, ok := CreateSwissRound(players, s.system); !ok {
+						b.Fatal("unable to pair the last round")
+					}
+				}
+			})
+		}
+	}
+}
+
+// player returns a player who already met the given opponents
+func player(id int64, score float64, rating int, opponents ...int64) Player {
+	p := Player{Id: id, Score: score, Rating: rating, Opponent: make(map[int64]struct{})}
+	for _, opponent := range opponents {
+		p.Opponent[opponent] = struct{}{}
+	}
+
+	return p
+}
+
+// colored returns the player after playing the given colours
+func colored(p Player, colors ...int) Player {
+	p.Colors = colors
+	return p
+}
+
+func TestCreateSwissRound(t *testing.T) {
+	tests := []struct {
+		name      string
+		system    PairingSystem
+		players   []Player
+		want      []Pairing
+		wantEmpty int64
+	}{
+		{"top half against bottom half", ScoreGroupSystem{}, []Player{
+			player(1, 0, 2400), player(2, 0, 2300), player(3, 0, 2200),
+			player(4, 0, 2100), player(5, 0, 2000), player(6, 0, 1900),
+		}, []Pairing{{1, 4}, {5, 2}, {3, 6}}, 0},
+		{"top half against bottom half in every score group", ScoreGroupSystem{}, []Player{
+			player(1, 1, 2400, 5), player(2, 1, 2300, 6), player(3, 1, 2200, 7), player(4, 1, 2100, 8),
+			player(5, 0, 2000, 1), player(6, 0, 1900, 2), player(7, 0, 1800, 3), player(8, 0, 1700, 4),
+		}, []Pairing{{1, 3}, {4, 2}, {5, 7}, {8, 6}}, 0},
+		{"lowest player floats down to the highest of the next group", ScoreGroupSystem{}, []Player{
+			player(1, 1, 2400, 4), player(2, 1, 2300, 5), player(3, 1, 2200, 6),
+			player(4, 0, 2100, 1), player(5, 0, 2000, 2), player(6, 0, 1900, 3),
+		}, []Pairing{{1, 2}, {4, 3}, {5, 6}}, 0},
+		{"floater skips the opponents already met", ScoreGroupSystem{}, []Player{
+			player(1, 1, 2400, 4), player(2, 1, 2300, 4), player(3, 1, 2200, 4), player(4, 0, 2100, 1, 2, 3, 6),
+			player(5, 0, 2000), player(6, 0, 1900, 4), player(7, 0, 1800), player(8, 0, 1700),
+		}, []Pairing{{1, 2}, {5, 3}, {4, 7}, {8, 6}}, 0},
+		{"transposition of S2", DutchSystem{}, []Player{
+			player(1, 0, 2400, 5), player(2, 0, 2300, 6), player(3, 0, 2200), player(4, 0, 2100),
+			player(5, 0, 2000, 1), player(6, 0, 1900, 2), player(7, 0, 1800), player(8, 0, 1700),
+		}, []Pairing{{1, 6}, {5, 2}, {3, 7}, {8, 4}}, 0},
+		{"transposition of S2 into the last pairs", DutchSystem{}, []Player{
+			player(1, 0, 2400, 4), player(2, 0, 2300, 5), player(3, 0, 2200),
+			player(4, 0, 2100, 1), player(5, 0, 2000, 2), player(6, 0, 1900),
+		}, []Pairing{{1, 5}, {4, 2}, {3, 6}}, 0},
+		{"same absolute colour preferences aren't paired", ScoreGroupSystem{}, []Player{
+			colored(player(1, 1, 2400, 2), ColorWhite, ColorWhite), colored(player(2, 1, 2300, 1), ColorBlack, ColorBlack),
+			colored(player(3, 1, 2200), ColorWhite, ColorWhite), colored(player(4, 1, 2100), ColorBlack, ColorBlack),
+		}, []Pairing{{4, 1}, {2, 3}}, 0},
+		{"bye for the lowest player", ScoreGroupSystem{}, []Player{
+			player(1, 0, 2400), player(2, 0, 2300), player(3, 0, 2200), player(4, 0, 2100), player(5, 0, 2000),
+		}, []Pairing{{1, 3}, {4, 2}}, 5},
+		{"bye for the lowest player without a previous bye", ScoreGroupSystem{}, []Player{
+			player(1, 0, 2400), player(2, 0, 2300), player(3, 0, 2200), player(4, 0, 2100), {Id: 5, Rating: 2000, HadBye: true},
+		}, []Pairing{{1, 3}, {5, 2}}, 4},
+		{"bye for a player whose absence keeps the round pairable", ScoreGroupSystem{}, []Player{
+			player(1, 0, 2400, 4), player(2, 0, 2300, 4), player(3, 0, 2200, 4), player(4, 0, 2100, 1, 2, 3), player(5, 0, 2000),
+		}, []Pairing{{1, 3}, {5, 2}}, 4},
+		{"second bye when everyone had one", ScoreGroupSystem{}, []Player{
+			{Id: 1, Rating: 2400, HadBye: true}, {Id: 2, Rating: 2300, HadBye: true}, {Id: 3, Rating: 2200, HadBye: true},
+		}, []Pairing{{1, 2}}, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, emptyPlayer, ok := CreateSwissRound(test.players, test.system)
+			if !ok {
+				t.Fatal("CreateSwissRound() failed")
+			}
+			if !slices.Equal(got, test.want) || emptyPlayer != test.wantEmpty {
+				t.Errorf("CreateSwissRound() = %v, %d, want %v, %d", got, emptyPlayer, test.want, test.wantEmpty)
+			}
+		})
+	}
+}
+
+func TestColorPreference(t *testing.T) {
+	tests := []struct {
+		name         string
+		colors       []int
+		wantColor    int
+		wantStrength int
+	}{
+		{"no games", nil, 0, PreferenceNone},
+		{"balanced colours", []int{ColorBlack, ColorWhite}, ColorBlack, PreferenceMild},
+		{"one more white", []int{ColorWhite}, ColorBlack, PreferenceStrong},
+		{"one more black", []int{ColorWhite, ColorBlack, ColorBlack}, ColorWhite, PreferenceAbsolute},
+		{"two more white", []int{ColorWhite, ColorBlack, ColorWhite, ColorWhite}, ColorBlack, PreferenceAbsolute},
+		{"same colour twice in a row", []int{ColorBlack, ColorWhite, ColorWhite}, ColorBlack, PreferenceAbsolute},
+		{"one more black without a streak", []int{ColorBlack, ColorWhite, ColorBlack}, ColorWhite, PreferenceStrong},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			color, strength := colored(Player{}, test.colors...).ColorPreference()
+			if color != test.wantColor || strength != test.wantStrength {
+				t.Errorf("ColorPreference() = %d, %d, want %d, %d", color, strength, test.wantColor, test.wantStrength)
+			}
+		})
+	}
+}
+
+func TestAllocateColors(t *testing.T) {
+	tests := []struct {
+		name    string
+		pairs   [][]int64
+		players []Player
+		want    []Pairing
+	}{
+		{"alternate colours without preferences", [][]int64{{1, 2}, {3, 4}}, []Player{
+			player(1, 0, 2400), player(2, 0, 2300), player(3, 0, 2200), player(4, 0, 2100),
+		}, []Pairing{{1, 2}, {4, 3}}},
+		{"boards ordered by score and rank", [][]int64{{1, 4}, {3, 2}}, []Player{
+			player(1, 0, 2400), player(2, 1, 2300), player(3, 1, 2200), player(4, 0, 2100),
+		}, []Pairing{{2, 3}, {4, 1}}},
+		{"both preferences granted", [][]int64{{1, 2}}, []Player{
+			colored(player(1, 1, 2400), ColorWhite, ColorBlack), colored(player(2, 1, 2300), ColorBlack, ColorWhite),
+		}, []Pairing{{1, 2}}},
+		{"preference of the lower player granted", [][]int64{{1, 2}}, []Player{
+			player(1, 1, 2400), colored(player(2, 1, 2300), ColorBlack),
+		}, []Pairing{{2, 1}}},
+		{"stronger preference granted", [][]int64{{1, 2}}, []Player{
+			colored(player(1, 1, 2400), ColorBlack, ColorWhite), colored(player(2, 1, 2300), ColorWhite),
+		}, []Pairing{{1, 2}}},
+		{"wider colour difference granted", [][]int64{{1, 2}}, []Player{
+			colored(player(1, 2, 2400), ColorWhite, ColorBlack, ColorWhite, ColorWhite),
+			colored(player(2, 2, 2300), ColorBlack, ColorWhite, ColorWhite),
+		}, []Pairing{{2, 1}}},
+		{"alternate from the last round with different colours", [][]int64{{1, 2}}, []Player{
+			colored(player(1, 2, 2400), ColorWhite, ColorBlack, ColorBlack, ColorWhite),
+			colored(player(2, 2, 2300), ColorBlack, ColorWhite, ColorBlack, ColorWhite),
+		}, []Pairing{{1, 2}}},
+		{"preference of the higher player granted", [][]int64{{1, 2}}, []Player{
+			colored(player(1, 1, 2400), ColorBlack, ColorWhite), colored(player(2, 1, 2300), ColorBlack, ColorWhite),
+		}, []Pairing{{2, 1}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := AllocateColors(test.pairs, test.players)
+			if !slices.Equal(got, test.want) {
+				t.Errorf("AllocateColors() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+// Player 1 can only meet players 4 and 5, who are both forbidden for them. The ideal pairing 1-4, 2-5, 3-6
+// breaks two forbidden pairings, the pairing systems must break only one
+func TestForbiddenPairings(t *testing.T) {
+	systems := []struct {
+		name   string
+		system PairingSystem
+	}{
+		{"score_groups", ScoreGroupSystem{}},
+		{"dutch", DutchSystem{}},
+	}
+
+	for _, s := range systems {
+		t.Run(s.name, func(t *testing.T) {
+			players := []Player{
+				player(1, 0, 2400, 2, 3, 6), player(2, 0, 2300, 1), player(3, 0, 2200, 1),
+				player(4, 0, 2100), player(5, 0, 2000), player(6, 0, 1900, 1),
+			}
+			players[0].Forbidden = map[int64]struct{}{4: {}, 5: {}}
+			players[1].Forbidden = map[int64]struct{}{5: {}}
+
+			pairings, _, ok := CreateSwissRound(players, s.system)
+			if !ok {
+				t.Fatal("CreateSwissRound() failed")
+			}
+
+			byID := make(map[int64]Player)
+			for _, player := range players {
+				byID[player.Id] = player
+			}
+
+			broken := 0
+			for _, pairing := range pairings {
+				if forbidden(byID[pairing.White], byID[pairing.Black]) {
+					broken++
+				}
+			}
+			if broken != 1 {
+				t.Errorf("CreateSwissRound() = %v breaks %d forbidden pairings, want 1", pairings, broken)
+			}
+		})
+	}
+}
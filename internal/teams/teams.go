@@ -0,0 +1,886 @@
+package teams
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	. "github.comPhantomvv1/SwissPairAPI/internal/auth"
+	. "github.comPhantomvv1/SwissPairAPI/internal/tournament"
+)
+
+type Team struct {
+	ID      int          `json:"id"`
+	Name    string       `json:"name"`
+	OwnerID int          `json:"owner_id"` // Captain of the team, who manages the roster
+	Members []TeamMember `json:"members"`
+}
+
+// TeamMember is a player on the roster of a team. The roster is ordered, the players higher
+// on it play the higher boards and the reserves come after the regular players
+type TeamMember struct {
+	UserID   int    `json:"user_id"`
+	Name     string `json:"name"`
+	Position int    `json:"position"`
+	Reserve  bool   `json:"reserve"`
+}
+
+// TournamentTeam is a team entered into a tournament, seeded by its rating
+type TournamentTeam struct {
+	TeamID int    `json:"team_id"`
+	Name   string `json:"name"`
+	Rating int    `json:"rating"`
+}
+
+var (
+	ErrShortRoster   = errors.New("Error a team doesn't have enough players for all the boards")
+	ErrInvalidLineup = errors.New("Error the lineup must have a player of the roster for every board in the order of the roster")
+)
+
+// CreateTeamsTables creates the tables of the teams, their rosters, their entries into tournaments, their lineups and their matches
+func CreateTeamsTables(conn *pgx.Conn) error {
+	tables := []string{
+		"create table if not exists teams (id serial primary key, name text, owner_id int references authentication(id), created_at timestamp)",
+		"create table if not exists team_members (team_id int references teams(id), user_id int references authentication(id), " +
+			"position int, reserve boolean default false, unique (team_id, user_id))",
+		"create table if not exists tournament_teams (tournament_id int references tournaments(id), team_id int references teams(id), " +
+			"rating int default 0, unique (tournament_id, team_id))",
+		"create table if not exists team_lineups (tournament_id int references tournaments(id), team_id int references teams(id), " +
+			"round int, players int[], unique (tournament_id, team_id, round))",
+		"create table if not exists team_matches (id serial primary key, tournament_id int references tournaments(id), round int, " +
+			"board int, team_1 int references teams(id), team_2 int references teams(id))",
+	}
+
+	for _, table := range tables {
+		if _, err := conn.Exec(context.Background(), table); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetTeam loads the team with its roster in the order of the boards
+func (t *Team) GetTeam(conn *pgx.Conn) error {
+	err := conn.QueryRow(context.Background(), "select name, owner_id from teams where id = $1", t.ID).Scan(&t.Name, &t.OwnerID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := conn.Query(context.Background(), "select m.user_id, a.name, m.position, m.reserve from team_members m "+
+		"join authentication a on a.id = m.user_id where m.team_id = $1 order by m.position", t.ID)
+	if err != nil {
+		return err
+	}
+
+	t.Members = make([]TeamMember, 0)
+	for rows.Next() {
+		member := TeamMember{}
+		if err = rows.Scan(&member.UserID, &member.Name, &member.Position, &member.Reserve); err != nil {
+			return err
+		}
+
+		t.Members = append(t.Members, member)
+	}
+
+	return rows.Err()
+}
+
+// GetTournamentTeams returns the teams entered into the tournament, the highest rated first
+func GetTournamentTeams(conn *pgx.Conn, tournamentID int) ([]TournamentTeam, error) {
+	rows, err := conn.Query(context.Background(), "select t.id, t.name, e.rating from tournament_teams e join teams t on t.id = e.team_id "+
+		"where e.tournament_id = $1 order by e.rating desc, t.id", tournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	teams := make([]TournamentTeam, 0)
+	for rows.Next() {
+		team := TournamentTeam{}
+		if err = rows.Scan(&team.TeamID, &team.Name, &team.Rating); err != nil {
+			return nil, err
+		}
+
+		teams = append(teams, team)
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return teams, nil
+}
+
+// GetLineup returns the players of the team for the boards of the round. Without a lineup set by the captain
+// the team plays with the players at the top of its roster
+func GetLineup(conn *pgx.Conn, tournamentID, teamID, round, boards int) ([]int, error) {
+	team := Team{ID: teamID}
+	if err := team.GetTeam(conn); err != nil {
+		return nil, err
+	}
+
+	var lineup []int
+	err := conn.QueryRow(context.Background(), "select players from team_lineups where tournament_id = $1 and team_id = $2 and round = $3",
+		tournamentID, teamID, round).Scan(&lineup)
+	if err == nil {
+		// The roster could have changed since the lineup was set
+		if !validLineup(lineup, team.Members, boards) {
+			return nil, ErrInvalidLineup
+		}
+
+		return lineup, nil
+	}
+
+	if err != pgx.ErrNoRows {
+		return nil, err
+	}
+
+	if len(team.Members) < boards {
+		return nil, ErrShortRoster
+	}
+
+	lineup = make([]int, 0, boards)
+	for _, member := range team.Members[:boards] {
+		lineup = append(lineup, member.UserID)
+	}
+
+	return lineup, nil
+}
+
+// GetLastTeamRound returns the number of the latest round that paired the teams of the tournament
+func GetLastTeamRound(conn *pgx.Conn, tournamentID int) (int, error) {
+	round := 0
+	err := conn.QueryRow(context.Background(), "select coalesce(max(round), 0) from team_matches where tournament_id = $1",
+		tournamentID).Scan(&round)
+	return round, err
+}
+
+// validLineup reports whether the lineup fills every board with a different player of the roster without changing their order
+func validLineup(lineup []int, members []TeamMember, boards int) bool {
+	if len(lineup) != boards {
+		return false
+	}
+
+	positions := make(map[int]int)
+	for _, member := range members {
+		positions[member.UserID] = member.Position
+	}
+
+	previous := 0
+	for _, id := range lineup {
+		position, ok := positions[id]
+		if !ok || position <= previous {
+			return false
+		}
+		previous = position
+	}
+
+	return true
+}
+
+// parseIDs reads a list of ids from the body of a request
+func parseIDs(value any) ([]int, bool) {
+	values, ok := value.([]any)
+	if !ok {
+		return nil, false
+	}
+
+	ids := make([]int, 0, len(values))
+	for _, value := range values {
+		id, ok := value.(float64)
+		if !ok {
+			return nil, false
+		}
+
+		ids = append(ids, int(id))
+	}
+
+	return ids, true
+}
+
+// parseRoster reads the regular players and the reserves of a team, each player can be on the roster only once
+func parseRoster(information map[string]any) ([]int, []int, bool) {
+	members, ok := parseIDs(information["members"])
+	if !ok || len(members) == 0 {
+		return nil, nil, false
+	}
+
+	reserves := make([]int, 0)
+	if value, has := information["reserves"]; has {
+		if reserves, ok = parseIDs(value); !ok {
+			return nil, nil, false
+		}
+	}
+
+	seen := make(map[int]struct{})
+	for _, id := range append(append([]int{}, members...), reserves...) {
+		if _, has := seen[id]; has {
+			return nil, nil, false
+		}
+		seen[id] = struct{}{}
+	}
+
+	return members, reserves, true
+}
+
+// saveRoster replaces the roster of the team, the reserves get the positions after the regular players
+func saveRoster(tx pgx.Tx, teamID int, members, reserves []int) error {
+	_, err := tx.Exec(context.Background(), "delete from team_members where team_id = $1", teamID)
+	if err != nil {
+		return err
+	}
+
+	for i, id := range append(append([]int{}, members...), reserves...) {
+		_, err = tx.Exec(context.Background(), "insert into team_members (team_id, user_id, position, reserve) values ($1, $2, $3, $4)",
+			teamID, id, i+1, i >= len(members))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// usersExist reports whether all the users have accounts
+func usersExist(conn *pgx.Conn, ids []int) (bool, error) {
+	count := 0
+	err := conn.QueryRow(context.Background(), "select count(*) from authentication where id = any($1)", ids).Scan(&count)
+	return count == len(ids), err
+}
+
+func CreateTeam(c *gin.Context) {
+	var information map[string]any
+	json.NewDecoder(c.Request.Body).Decode(&information) // token && name && members && (reserves)
+
+	token, ok := information["token"].(string)
+	if !ok {
+		log.Println("Incorrectly provided token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided token"})
+		return
+	}
+
+	id, _, err := ValidateJWT(token)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Error invalid token"})
+		return
+	}
+
+	name, ok := information["name"].(string)
+	if !ok || name == "" {
+		log.Println("Incorrectly provided name of the team")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided name of the team"})
+		return
+	}
+
+	members, reserves, ok := parseRoster(information)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided roster of the team"})
+		return
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if err = CreateTeamsTables(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the tables for the teams"})
+		return
+	}
+
+	exist, err := usersExist(conn, append(append([]int{}, members...), reserves...))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to check the players of the team"})
+		return
+	}
+
+	if !exist {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Error not all the players of the team have accounts"})
+		return
+	}
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the team"})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	team := Team{Name: name, OwnerID: id}
+	err = tx.QueryRow(context.Background(), "insert into teams (name, owner_id, created_at) values ($1, $2, current_timestamp) returning id",
+		name, id).Scan(&team.ID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the team"})
+		return
+	}
+
+	if err = saveRoster(tx, team.ID, members, reserves); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to save the roster of the team"})
+		return
+	}
+
+	if err = tx.Commit(context.Background()); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the team"})
+		return
+	}
+
+	if err = team.GetTeam(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the team"})
+		return
+	}
+
+	c.JSON(http.StatusOK, team)
+}
+
+func GetTeamInfo(c *gin.Context) {
+	teamID, err := strconv.Atoi(c.Param("teamID"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the team"})
+		return
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if err = CreateTeamsTables(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the tables for the teams"})
+		return
+	}
+
+	team := Team{ID: teamID}
+	if err = team.GetTeam(conn); err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a team with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the team"})
+		return
+	}
+
+	c.JSON(http.StatusOK, team)
+}
+
+// UpdateRoster replaces the roster of the team. The lineups already set for tournaments are kept
+func UpdateRoster(c *gin.Context) {
+	var information map[string]any
+	json.NewDecoder(c.Request.Body).Decode(&information) // token && teamID && members && (reserves)
+
+	token, ok := information["token"].(string)
+	if !ok {
+		log.Println("Incorrectly provided token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided token"})
+		return
+	}
+
+	id, accType, err := ValidateJWT(token)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Error invalid token"})
+		return
+	}
+
+	teamIDFl, ok := information["teamID"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided id of the team")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the team"})
+		return
+	}
+	teamID := int(teamIDFl)
+
+	members, reserves, ok := parseRoster(information)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided roster of the team"})
+		return
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if err = CreateTeamsTables(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the tables for the teams"})
+		return
+	}
+
+	team := Team{ID: teamID}
+	if err = team.GetTeam(conn); err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a team with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the team"})
+		return
+	}
+
+	if accType != Admin && id != team.OwnerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Error only admins and the captain of the team can change its roster"})
+		return
+	}
+
+	exist, err := usersExist(conn, append(append([]int{}, members...), reserves...))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to check the players of the team"})
+		return
+	}
+
+	if !exist {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Error not all the players of the team have accounts"})
+		return
+	}
+
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to save the roster of the team"})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	if err = saveRoster(tx, teamID, members, reserves); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to save the roster of the team"})
+		return
+	}
+
+	if err = tx.Commit(context.Background()); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to save the roster of the team"})
+		return
+	}
+
+	if err = team.GetTeam(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the team"})
+		return
+	}
+
+	c.JSON(http.StatusOK, team)
+}
+
+// EnterTeam enters a team into a team tournament that hasn't started yet. A player can play for only one team of the tournament
+func EnterTeam(c *gin.Context) {
+	var information map[string]any
+	json.NewDecoder(c.Request.Body).Decode(&information) // token && tournamentID && teamID && (rating)
+
+	token, ok := information["token"].(string)
+	if !ok {
+		log.Println("Incorrectly provided token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided token"})
+		return
+	}
+
+	id, accType, err := ValidateJWT(token)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Error invalid token"})
+		return
+	}
+
+	tournamentIDFl, ok := information["tournamentID"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided id of the tournament")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return
+	}
+	tournamentID := int(tournamentIDFl)
+
+	teamIDFl, ok := information["teamID"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided id of the team")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the team"})
+		return
+	}
+	teamID := int(teamIDFl)
+
+	rating := 0
+	if value, has := information["rating"]; has {
+		ratingFl, ok := value.(float64)
+		if !ok || ratingFl < 0 || ratingFl > 4000 {
+			log.Println("Incorrectly provided rating")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided rating"})
+			return
+		}
+		rating = int(ratingFl)
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if err = CreateTeamsTables(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the tables for the teams"})
+		return
+	}
+
+	tournament := Tournament{ID: tournamentID}
+	if err = tournament.GetTournament(conn); err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a tournament with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the tournament"})
+		return
+	}
+
+	if accType != Admin && id != tournament.OwnerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Error only admins and owners can enter teams"})
+		return
+	}
+
+	if tournament.TeamBoards == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error the tournament isn't played by teams"})
+		return
+	}
+
+	if !tournament.BeforeStart() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Error teams can only enter a tournament that hasn't started"})
+		return
+	}
+
+	team := Team{ID: teamID}
+	if err = team.GetTeam(conn); err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a team with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the team"})
+		return
+	}
+
+	if len(team.Members) < tournament.TeamBoards {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrShortRoster.Error()})
+		return
+	}
+
+	players := make([]int, 0, len(team.Members))
+	for _, member := range team.Members {
+		players = append(players, member.UserID)
+	}
+
+	entered := 0
+	err = conn.QueryRow(context.Background(), "select count(*) from tournament_teams e join team_members m on m.team_id = e.team_id "+
+		"where e.tournament_id = $1 and (e.team_id = $2 or m.user_id = any($3))", tournamentID, teamID, players).Scan(&entered)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to check the teams of the tournament"})
+		return
+	}
+
+	if entered != 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Error the team or some of its players already play in this tournament"})
+		return
+	}
+
+	_, err = conn.Exec(context.Background(), "insert into tournament_teams (tournament_id, team_id, rating) values ($1, $2, $3)",
+		tournamentID, teamID, rating)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to enter the team"})
+		return
+	}
+
+	c.JSON(http.StatusOK, TournamentTeam{TeamID: teamID, Name: team.Name, Rating: rating})
+}
+
+// RemoveTeamFromTournament takes a team out of a tournament that hasn't started yet
+func RemoveTeamFromTournament(c *gin.Context) {
+	var information map[string]any
+	json.NewDecoder(c.Request.Body).Decode(&information) // token && tournamentID && teamID
+
+	token, ok := information["token"].(string)
+	if !ok {
+		log.Println("Incorrectly provided token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided token"})
+		return
+	}
+
+	id, accType, err := ValidateJWT(token)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Error invalid token"})
+		return
+	}
+
+	tournamentIDFl, ok := information["tournamentID"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided id of the tournament")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return
+	}
+	tournamentID := int(tournamentIDFl)
+
+	teamIDFl, ok := information["teamID"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided id of the team")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the team"})
+		return
+	}
+	teamID := int(teamIDFl)
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if err = CreateTeamsTables(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the tables for the teams"})
+		return
+	}
+
+	tournament := Tournament{ID: tournamentID}
+	if err = tournament.GetTournament(conn); err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a tournament with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the tournament"})
+		return
+	}
+
+	if accType != Admin && id != tournament.OwnerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Error only admins and owners can remove teams"})
+		return
+	}
+
+	if !tournament.BeforeStart() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Error teams can only be removed from a tournament that hasn't started"})
+		return
+	}
+
+	removed := 0
+	err = conn.QueryRow(context.Background(), "delete from tournament_teams where tournament_id = $1 and team_id = $2 returning team_id",
+		tournamentID, teamID).Scan(&removed)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error the team doesn't play in this tournament"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to remove the team"})
+		return
+	}
+
+	c.JSON(http.StatusOK, nil)
+}
+
+func GetTeamsForTournament(c *gin.Context) {
+	tournamentID, err := strconv.Atoi(c.Param("tournamentID"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if err = CreateTeamsTables(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the tables for the teams"})
+		return
+	}
+
+	teams, err := GetTournamentTeams(conn, tournamentID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the teams of the tournament"})
+		return
+	}
+
+	c.JSON(http.StatusOK, teams)
+}
+
+// SetLineup lets the captain choose the players of the team for a round that hasn't been paired yet.
+// The players keep the order of the roster, so a reserve can only play below the regular players
+func SetLineup(c *gin.Context) {
+	var information map[string]any
+	json.NewDecoder(c.Request.Body).Decode(&information) // token && tournamentID && teamID && round && players
+
+	token, ok := information["token"].(string)
+	if !ok {
+		log.Println("Incorrectly provided token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided token"})
+		return
+	}
+
+	id, accType, err := ValidateJWT(token)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Error invalid token"})
+		return
+	}
+
+	tournamentIDFl, ok := information["tournamentID"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided id of the tournament")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return
+	}
+	tournamentID := int(tournamentIDFl)
+
+	teamIDFl, ok := information["teamID"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided id of the team")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the team"})
+		return
+	}
+	teamID := int(teamIDFl)
+
+	roundFl, ok := information["round"].(float64)
+	if !ok || roundFl < 1 {
+		log.Println("Incorrectly provided round")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided round"})
+		return
+	}
+	round := int(roundFl)
+
+	lineup, ok := parseIDs(information["players"])
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided players of the lineup"})
+		return
+	}
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if err = CreateTeamsTables(conn); err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to create the tables for the teams"})
+		return
+	}
+
+	tournament := Tournament{ID: tournamentID}
+	if err = tournament.GetTournament(conn); err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a tournament with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the tournament"})
+		return
+	}
+
+	team := Team{ID: teamID}
+	if err = team.GetTeam(conn); err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a team with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the team"})
+		return
+	}
+
+	if accType != Admin && id != team.OwnerID && id != tournament.OwnerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Error only admins, the owner of the tournament and the captain of the team can set its lineup"})
+		return
+	}
+
+	entered := 0
+	err = conn.QueryRow(context.Background(), "select count(*) from tournament_teams where tournament_id = $1 and team_id = $2",
+		tournamentID, teamID).Scan(&entered)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to check the teams of the tournament"})
+		return
+	}
+
+	if entered == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Error the team doesn't play in this tournament"})
+		return
+	}
+
+	if tournament.IsOver() {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrTournamentOver.Error()})
+		return
+	}
+
+	lastRound, err := GetLastTeamRound(conn, tournamentID)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the current round of the tournament"})
+		return
+	}
+
+	if round <= lastRound {
+		c.JSON(http.StatusConflict, gin.H{"error": "Error the round has already been paired"})
+		return
+	}
+
+	if !validLineup(lineup, team.Members, tournament.TeamBoards) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidLineup.Error()})
+		return
+	}
+
+	_, err = conn.Exec(context.Background(), "insert into team_lineups (tournament_id, team_id, round, players) values ($1, $2, $3, $4) "+
+		"on conflict (tournament_id, team_id, round) do update set players = excluded.players", tournamentID, teamID, round, lineup)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to save the lineup"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"team_id": teamID, "round": round, "players": lineup})
+}
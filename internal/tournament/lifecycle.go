@@ -0,0 +1,174 @@
+package tournament
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	. "github.comPhantomvv1/SwissPairAPI/internal/auth"
+)
+
+var (
+	ErrInvalidTransition = errors.New("Error the tournament can't go to this status from its current one")
+	ErrTournamentOver    = errors.New("Error the tournament is already finished, archived or cancelled")
+	ErrNotInProgress     = errors.New("Error the tournament isn't in progress")
+	ErrCannotPair        = errors.New("Error the registration of the tournament has to be opened before its rounds are paired")
+	ErrAlreadyStarted    = errors.New("Error the tournament has already started")
+)
+
+// transitions are the statuses a tournament can go to from every status. A tournament is finished by FinishTournament,
+// which rates its games, and goes back from in progress to registration closed only when its first round is deleted
+var transitions = map[int][]int{
+	StatusDraft:              {StatusRegistrationOpen, StatusCancelled},
+	StatusRegistrationOpen:   {StatusRegistrationClosed, StatusInProgress, StatusCancelled},
+	StatusRegistrationClosed: {StatusRegistrationOpen, StatusInProgress, StatusCancelled},
+	StatusInProgress:         {StatusFinished, StatusCancelled},
+	StatusFinished:           {StatusArchived},
+	StatusCancelled:          {StatusArchived},
+}
+
+// ParseStatus converts the name of a status to the value stored for the tournament. The names of
+// the statuses from before the lifecycle still work
+func ParseStatus(status string) (int, bool) {
+	switch status {
+	case "draft":
+		return StatusDraft, true
+	case "registration_open", "pending":
+		return StatusRegistrationOpen, true
+	case "registration_closed":
+		return StatusRegistrationClosed, true
+	case "in_progress", "active":
+		return StatusInProgress, true
+	case "finished":
+		return StatusFinished, true
+	case "archived":
+		return StatusArchived, true
+	case "cancelled":
+		return StatusCancelled, true
+	}
+
+	return 0, false
+}
+
+// CanTransition reports whether a tournament can go from one status to the other
+func CanTransition(from, to int) bool {
+	return slices.Contains(transitions[from], to)
+}
+
+// IsOver reports whether the tournament is finished, archived or cancelled, so that nothing in it can change anymore
+func (t Tournament) IsOver() bool {
+	return t.Status == StatusFinished || t.Status == StatusArchived || t.Status == StatusCancelled
+}
+
+// BeforeStart reports whether the tournament is still being prepared or registering its players
+func (t Tournament) BeforeStart() bool {
+	return t.Status == StatusDraft || t.Status == StatusRegistrationOpen || t.Status == StatusRegistrationClosed
+}
+
+// CanPair reports whether rounds can be paired in the tournament. Pairing the first round starts the tournament
+func (t Tournament) CanPair() bool {
+	return t.Status == StatusInProgress || CanTransition(t.Status, StatusInProgress)
+}
+
+// transitionTournament moves the tournament of the request to the given status when the owner asks for it
+func transitionTournament(c *gin.Context, to int) {
+	var information map[string]any
+	json.NewDecoder(c.Request.Body).Decode(&information) // token && tournamentID
+
+	token, ok := information["token"].(string)
+	if !ok {
+		log.Println("Incorrectly provided token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided token"})
+		return
+	}
+
+	id, accType, err := ValidateJWT(token)
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Error invalid token"})
+		return
+	}
+
+	tournamentIDFl, ok := information["tournamentID"].(float64)
+	if !ok {
+		log.Println("Incorrectly provided id of the tournament")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided id of the tournament"})
+		return
+	}
+	tournamentID := int(tournamentIDFl)
+
+	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to connect to the database"})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	tournament := Tournament{ID: tournamentID}
+	if err = tournament.GetTournament(conn); err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a tournament with this id doesn't exist"})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the information about the tournament"})
+		return
+	}
+
+	if accType != Admin && id != tournament.OwnerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Error only admins and the owner of the tournament can change its status"})
+		return
+	}
+
+	if !CanTransition(tournament.Status, to) {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrInvalidTransition.Error()})
+		return
+	}
+
+	// The status is only changed if nobody changed it in the meantime
+	changed := 0
+	err = conn.QueryRow(context.Background(), "update tournaments set status = $1, updated_at = current_timestamp where id = $2 "+
+		"and status = $3 returning id", to, tournamentID, tournament.Status).Scan(&changed)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusConflict, gin.H{"error": ErrInvalidTransition.Error()})
+			return
+		}
+
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to change the status of the tournament"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": to})
+}
+
+// OpenRegistration opens the registration of a draft tournament or opens it again after it was closed
+func OpenRegistration(c *gin.Context) {
+	transitionTournament(c, StatusRegistrationOpen)
+}
+
+func CloseRegistration(c *gin.Context) {
+	transitionTournament(c, StatusRegistrationClosed)
+}
+
+// StartTournament starts the tournament without pairing its first round, which also starts it
+func StartTournament(c *gin.Context) {
+	transitionTournament(c, StatusInProgress)
+}
+
+func CancelTournament(c *gin.Context) {
+	transitionTournament(c, StatusCancelled)
+}
+
+func ArchiveTournament(c *gin.Context) {
+	transitionTournament(c, StatusArchived)
+}
@@ -0,0 +1,86 @@
+package tournament
+
+import (
+	"math"
+	"strconv"
+)
+
+// Scoring are the points the players get for the results of their games. All the points are multiples
+// of half a point, which keeps the score groups of the Swiss pairing apart
+type Scoring struct {
+	Win            float64 `json:"win"`
+	Draw           float64 `json:"draw"`
+	Loss           float64 `json:"loss"`
+	Draws          int     `json:"draws"`
+	ArmageddonWin  float64 `json:"armageddon_win"`  // Points of the winner of the armageddon game played after a draw
+	ArmageddonLoss float64 `json:"armageddon_loss"` // Points of the loser of the armageddon game played after a draw
+}
+
+const (
+	DrawsAllowed    = iota + 1
+	DrawsForbidden  // Every game has a winner, like go with komi
+	DrawsArmageddon // A drawn game is decided by an armageddon game
+)
+
+// DefaultScoring is the classical chess scoring
+var DefaultScoring = Scoring{Win: 1, Draw: 0.5, Loss: 0, Draws: DrawsAllowed}
+
+// ParseScoring returns the preset scoring system with the given name
+func ParseScoring(name string) (Scoring, bool) {
+	switch name {
+	case "chess":
+		return DefaultScoring, true
+	case "football":
+		return Scoring{Win: 3, Draw: 1, Loss: 0, Draws: DrawsAllowed}, true
+	case "no_draws":
+		return Scoring{Win: 1, Loss: 0, Draws: DrawsForbidden}, true
+	case "armageddon":
+		return Scoring{Win: 3, Loss: 0, Draws: DrawsArmageddon, ArmageddonWin: 1.5, ArmageddonLoss: 1}, true
+	}
+
+	return Scoring{}, false
+}
+
+// validPoints reports whether the points are a multiple of half a point between 0 and 10
+func validPoints(points float64) bool {
+	return points >= 0 && points <= 10 && points*2 == math.Trunc(points*2)
+}
+
+// Valid reports whether all the points are valid and a win is worth more than a loss. A win is worth whole
+// points, so that the half-point byes are multiples of half a point too
+func (s Scoring) Valid() bool {
+	for _, points := range []float64{s.Win, s.Draw, s.Loss, s.ArmageddonWin, s.ArmageddonLoss} {
+		if !validPoints(points) {
+			return false
+		}
+	}
+
+	return s.Win == math.Trunc(s.Win) && s.Win > s.Loss && s.ArmageddonWin >= s.ArmageddonLoss
+}
+
+// parseScoring reads the scoring of a new tournament from a preset and the points that override it
+func parseScoring(information map[string]string) (Scoring, bool) {
+	scoring := DefaultScoring
+	if name, ok := information["scoring"]; ok {
+		if scoring, ok = ParseScoring(name); !ok {
+			return Scoring{}, false
+		}
+	}
+
+	overrides := map[string]*float64{"pointsWin": &scoring.Win, "pointsDraw": &scoring.Draw, "pointsLoss": &scoring.Loss,
+		"armageddonWin": &scoring.ArmageddonWin, "armageddonLoss": &scoring.ArmageddonLoss}
+	for key, points := range overrides {
+		value, ok := information[key]
+		if !ok {
+			continue
+		}
+
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return Scoring{}, false
+		}
+		*points = parsed
+	}
+
+	return scoring, scoring.Valid()
+}
@@ -7,45 +7,191 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5"
 	. "github.comPhantomvv1/SwissPairAPI/internal/auth"
 	. "github.comPhantomvv1/SwissPairAPI/internal/emails"
+	. "github.comPhantomvv1/SwissPairAPI/internal/swiss"
 )
 
 type Tournament struct {
-	ID        int        `json:"id"`
-	Name      string     `json:"name"`
-	OwnerID   int        `json:"owner_id"`
-	Status    int        `json:"status"`
-	Start     time.Time  `json:"start"`
-	CreatedAt *time.Time `json:"created_at,omitempty"`
-	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	ID            int        `json:"id"`
+	Name          string     `json:"name"`
+	OwnerID       int        `json:"owner_id"`
+	Status        int        `json:"status"`
+	Start         time.Time  `json:"start"`
+	Format        int        `json:"format"`
+	PairingSystem int        `json:"pairing_system"`
+	SelfReporting bool       `json:"self_reporting"`
+	ByeValue      float64    `json:"bye_value"` // Share of the points of a win that a pairing-allocated bye is worth
+	Tiebreaks     []string   `json:"tiebreaks"`
+	SwissRounds   int        `json:"swiss_rounds"` // Swiss rounds before the cut of a Swiss then knockout tournament
+	Cut           int        `json:"cut"`          // Number of players who go from the Swiss rounds to the knockout bracket
+	KFactor       int        `json:"k_factor"`     // Fixed K-factor of the rating changes, 0 for the K-factor of every player
+	RatingSystem  int        `json:"rating_system"`
+	Acceleration  int        `json:"acceleration"` // Accelerated rounds of the Baku acceleration, 0 without acceleration
+	Scoring       Scoring    `json:"scoring"`
+	BestOf        int        `json:"best_of"`      // Games of every match when the pairings are played as matches, 0 for single games
+	TeamBoards    int        `json:"team_boards"`  // Boards of every team match, 0 when individual players enter the tournament
+	TeamRanking   int        `json:"team_ranking"` // Whether the teams are ranked by match points or by board points first
+	MaxPlayers    int        `json:"max_players"`  // Players who can register themselves before the waitlist starts, 0 without a limit
+	Approval      bool       `json:"approval"`     // Whether the owner approves the players who register themselves
+	CreatedAt     *time.Time `json:"created_at,omitempty"`
+	UpdatedAt     *time.Time `json:"updated_at,omitempty"`
 }
 
+// Statuses of the lifecycle of a tournament, see the transitions between them. The first three keep
+// the values of the statuses from before the lifecycle
 const (
-	StatusPending = iota + 1
-	StatusActive
+	StatusRegistrationOpen = iota + 1
+	StatusInProgress
 	StatusFinished
+	StatusDraft // Created, but not open for the registration yet
+	StatusRegistrationClosed
+	StatusArchived
+	StatusCancelled
 )
 
 func (t *Tournament) GetTournament(conn *pgx.Conn) error {
-	err := conn.QueryRow(context.Background(), "select name, owner_id, status, start from tournaments where id = $1", t.ID).Scan(
-		&t.Name, &t.OwnerID, &t.Status, &t.Start)
+	err := conn.QueryRow(context.Background(), "select name, owner_id, status, start, format, pairing_system, self_reporting, bye_value, "+
+		"tiebreaks, swiss_rounds, cut, k_factor, rating_system, acceleration, points_win, points_draw, points_loss, draws, armageddon_win, "+
+		"armageddon_loss, best_of, team_boards, team_ranking, max_players, approval from tournaments where id = $1", t.ID).Scan(&t.Name,
+		&t.OwnerID, &t.Status, &t.Start, &t.Format, &t.PairingSystem, &t.SelfReporting, &t.ByeValue, &t.Tiebreaks, &t.SwissRounds, &t.Cut,
+		&t.KFactor, &t.RatingSystem, &t.Acceleration, &t.Scoring.Win, &t.Scoring.Draw, &t.Scoring.Loss, &t.Scoring.Draws, &t.Scoring.ArmageddonWin,
+		&t.Scoring.ArmageddonLoss, &t.BestOf, &t.TeamBoards, &t.TeamRanking, &t.MaxPlayers, &t.Approval)
 	return err
 }
 
 func (t *Tournament) GetTournamentAdmin(conn *pgx.Conn) error {
-	err := conn.QueryRow(context.Background(), "select name, owner_id, status, start, created_at, updated_at from tournaments where id = $1",
-		t.ID).Scan(&t.Name, &t.OwnerID, &t.Status, &t.Start, t.CreatedAt, t.UpdatedAt)
+	err := conn.QueryRow(context.Background(), "select name, owner_id, status, start, format, pairing_system, self_reporting, bye_value, "+
+		"tiebreaks, swiss_rounds, cut, k_factor, rating_system, acceleration, points_win, points_draw, points_loss, draws, armageddon_win, "+
+		"armageddon_loss, best_of, team_boards, team_ranking, max_players, approval, created_at, updated_at from tournaments where id = $1",
+		t.ID).Scan(&t.Name, &t.OwnerID, &t.Status, &t.Start, &t.Format, &t.PairingSystem, &t.SelfReporting, &t.ByeValue, &t.Tiebreaks,
+		&t.SwissRounds, &t.Cut, &t.KFactor, &t.RatingSystem, &t.Acceleration, &t.Scoring.Win, &t.Scoring.Draw, &t.Scoring.Loss, &t.Scoring.Draws,
+		&t.Scoring.ArmageddonWin, &t.Scoring.ArmageddonLoss, &t.BestOf, &t.TeamBoards, &t.TeamRanking, &t.MaxPlayers, &t.Approval, &t.CreatedAt,
+		&t.UpdatedAt)
 	return err
 }
 
+const (
+	FormatSwiss = iota + 1
+	FormatRoundRobin
+	FormatDoubleRoundRobin
+	FormatSingleElimination
+	FormatDoubleElimination
+	FormatSwissKnockout // Swiss rounds followed by a single elimination bracket for the top players
+)
+
+// ParseFormat converts the name of a tournament format to the value stored for the tournament
+func ParseFormat(format string) (int, bool) {
+	switch format {
+	case "swiss":
+		return FormatSwiss, true
+	case "round_robin":
+		return FormatRoundRobin, true
+	case "double_round_robin":
+		return FormatDoubleRoundRobin, true
+	case "single_elimination":
+		return FormatSingleElimination, true
+	case "double_elimination":
+		return FormatDoubleElimination, true
+	case "swiss_knockout":
+		return FormatSwissKnockout, true
+	}
+
+	return 0, false
+}
+
+const (
+	TeamRankingMatchPoints = iota + 1 // Match points first, board points break the ties
+	TeamRankingBoardPoints            // Board points first, match points break the ties
+)
+
+// ParseTeamRanking converts the name of the primary criterion of a team tournament to the value stored for the tournament
+func ParseTeamRanking(ranking string) (int, bool) {
+	switch ranking {
+	case "match_points":
+		return TeamRankingMatchPoints, true
+	case "board_points":
+		return TeamRankingBoardPoints, true
+	}
+
+	return 0, false
+}
+
+const (
+	RatingElo = iota + 1
+	RatingGlicko2
+)
+
+// ParseRatingSystem converts the name of a rating system to the value stored for the tournament
+func ParseRatingSystem(system string) (int, bool) {
+	switch system {
+	case "elo":
+		return RatingElo, true
+	case "glicko2":
+		return RatingGlicko2, true
+	}
+
+	return 0, false
+}
+
+// ParsePairingSystem converts the name of a pairing system to the value stored for the tournament
+func ParsePairingSystem(system string) (int, bool) {
+	switch system {
+	case "score_groups":
+		return SystemScoreGroups, true
+	case "dutch":
+		return SystemDutch, true
+	}
+
+	return 0, false
+}
+
+const (
+	TiebreakBuchholz         = "buchholz"
+	TiebreakBuchholzCut1     = "buchholz_cut1"
+	TiebreakMedianBuchholz   = "median_buchholz"
+	TiebreakSonnebornBerger  = "sonneborn_berger"
+	TiebreakProgressiveScore = "progressive_score"
+	TiebreakDirectEncounter  = "direct_encounter"
+	TiebreakWins             = "wins"
+	TiebreakBlacks           = "blacks"
+
+	// Tiebreaks of the card game events played in matches
+	TiebreakOpponentMatchWin = "opponent_match_win"
+	TiebreakGameWin          = "game_win"
+	TiebreakOpponentGameWin  = "opponent_game_win"
+)
+
+var DefaultTiebreaks = []string{TiebreakBuchholzCut1, TiebreakBuchholz, TiebreakSonnebornBerger}
+
+// ParseTiebreaks converts a comma separated list of tiebreaks to the order stored for the tournament
+func ParseTiebreaks(list string) ([]string, bool) {
+	tiebreaks := make([]string, 0)
+	for _, tiebreak := range strings.Split(list, ",") {
+		tiebreak = strings.TrimSpace(tiebreak)
+		switch tiebreak {
+		case TiebreakBuchholz, TiebreakBuchholzCut1, TiebreakMedianBuchholz, TiebreakSonnebornBerger,
+			TiebreakProgressiveScore, TiebreakDirectEncounter, TiebreakWins, TiebreakBlacks, TiebreakOpponentMatchWin, TiebreakGameWin,
+			TiebreakOpponentGameWin:
+			tiebreaks = append(tiebreaks, tiebreak)
+		case "":
+		default:
+			return nil, false
+		}
+	}
+
+	return tiebreaks, true
+}
+
 func GetTournamentOwnerID(conn *pgx.Conn, tournamentID int) (int, error) {
 	ownerID := 0
-	err := conn.QueryRow(context.Background(), "select owner_id from tournaments where tournament_id = $1", tournamentID).Scan(&ownerID)
+	err := conn.QueryRow(context.Background(), "select owner_id from tournaments where id = $1", tournamentID).Scan(&ownerID)
 	if err != nil {
 		return 0, err
 	}
@@ -55,8 +201,13 @@ func GetTournamentOwnerID(conn *pgx.Conn, tournamentID int) (int, error) {
 
 func CreateTournamentsTable(conn *pgx.Conn) error {
 	_, err := conn.Exec(context.Background(), "create table if not exists tournaments "+
-		"(id serial primary key, name text, owner_id int references authentication (id) "+
-		"status int check(status in (1, 2, 3)), start timestamp, created_at timestamp, updated_at timestamp)")
+		"(id serial primary key, name text, owner_id int references authentication (id), "+
+		"status int check(status between 1 and 7), start timestamp, format int default 1, pairing_system int default 1, self_reporting boolean default false, "+
+		"bye_value real default 1 check(bye_value in (0, 0.5, 1)), tiebreaks text[], swiss_rounds int default 0, cut int default 0, "+
+		"k_factor int default 0, rating_system int default 1, acceleration int default 0, "+
+		"points_win real default 1, points_draw real default 0.5, points_loss real default 0, draws int default 1, armageddon_win real default 0, "+
+		"armageddon_loss real default 0, best_of int default 0, team_boards int default 0, team_ranking int default 1, "+
+		"max_players int default 0, approval boolean default false, created_at timestamp, updated_at timestamp)")
 	if err != nil {
 		return err
 	}
@@ -66,7 +217,7 @@ func CreateTournamentsTable(conn *pgx.Conn) error {
 
 func CreateTournament(c *gin.Context) { // test
 	var information map[string]string
-	json.NewDecoder(c.Request.Body).Decode(&information) // name && start && token
+	json.NewDecoder(c.Request.Body).Decode(&information) // name && start && token && (format) && (pairingSystem) && (selfReporting) && (byeValue) && (tiebreaks) && (swissRounds && cut) && (kFactor) && (ratingSystem) && (acceleration) && (scoring) && (pointsWin) && (pointsDraw) && (pointsLoss) && (armageddonWin) && (armageddonLoss) && (bestOf) && (teamBoards) && (teamRanking) && (maxPlayers) && (approval)
 
 	token, ok := information["token"]
 	if !ok {
@@ -103,6 +254,139 @@ func CreateTournament(c *gin.Context) { // test
 		return
 	}
 
+	format := FormatSwiss
+	if value, ok := information["format"]; ok {
+		format, ok = ParseFormat(value)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error invalid format of the tournament"})
+			return
+		}
+	}
+
+	pairingSystem := SystemScoreGroups
+	if system, ok := information["pairingSystem"]; ok {
+		pairingSystem, ok = ParsePairingSystem(system)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error invalid pairing system"})
+			return
+		}
+	}
+
+	selfReporting := information["selfReporting"] == "true"
+
+	byeValue := 1.0
+	if value, ok := information["byeValue"]; ok {
+		byeValue, err = strconv.ParseFloat(value, 64)
+		if err != nil || (byeValue != 0 && byeValue != 0.5 && byeValue != 1) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error the value of a bye can only be 1, 0.5 or 0"})
+			return
+		}
+	}
+
+	tiebreaks := DefaultTiebreaks
+	if value, ok := information["tiebreaks"]; ok {
+		tiebreaks, ok = ParseTiebreaks(value)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error invalid tiebreaks"})
+			return
+		}
+	}
+
+	scoring, ok := parseScoring(information)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error invalid scoring, the points must be multiples of 0.5 between 0 and 10 and a win must be worth whole points more than a loss"})
+		return
+	}
+
+	bestOf := 0
+	if value, ok := information["bestOf"]; ok {
+		bestOf, err = strconv.Atoi(value)
+		if err != nil || bestOf < 1 || bestOf > 9 || bestOf%2 == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error the number of games of a match must be odd and between 1 and 9"})
+			return
+		}
+	}
+
+	swissRounds, cut := 0, 0
+	if format == FormatSwissKnockout {
+		swissRounds, err = strconv.Atoi(information["swissRounds"])
+		if err != nil || swissRounds < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided number of Swiss rounds"})
+			return
+		}
+
+		cut, err = strconv.Atoi(information["cut"])
+		if err != nil || cut < 2 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided number of players for the knockout bracket"})
+			return
+		}
+	}
+
+	kFactor := 0
+	if value, ok := information["kFactor"]; ok {
+		kFactor, err = strconv.Atoi(value)
+		if err != nil || kFactor < 1 || kFactor > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error the K-factor must be between 1 and 100"})
+			return
+		}
+	}
+
+	ratingSystem := RatingElo
+	if system, ok := information["ratingSystem"]; ok {
+		ratingSystem, ok = ParseRatingSystem(system)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error invalid rating system"})
+			return
+		}
+	}
+
+	acceleration := 0
+	if value, ok := information["acceleration"]; ok {
+		if format != FormatSwiss && format != FormatSwissKnockout {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error only Swiss rounds can be accelerated"})
+			return
+		}
+
+		acceleration, err = strconv.Atoi(value)
+		if err != nil || acceleration < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error incorrectly provided number of accelerated rounds"})
+			return
+		}
+	}
+
+	teamBoards, teamRanking := 0, TeamRankingMatchPoints
+	if value, ok := information["teamBoards"]; ok {
+		if format != FormatSwiss {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error only Swiss tournaments can be played by teams"})
+			return
+		}
+
+		teamBoards, err = strconv.Atoi(value)
+		if err != nil || teamBoards < 2 || teamBoards > 12 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error the number of boards of a team match must be between 2 and 12"})
+			return
+		}
+	}
+
+	if ranking, ok := information["teamRanking"]; ok {
+		teamRanking, ok = ParseTeamRanking(ranking)
+		if !ok || teamBoards == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error invalid ranking of the teams"})
+			return
+		}
+	}
+
+	maxPlayers := 0
+	if value, ok := information["maxPlayers"]; ok {
+		maxPlayers, err = strconv.Atoi(value)
+		if err != nil || maxPlayers < 2 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error the maximum number of players must be at least 2"})
+			return
+		}
+	}
+
+	approval := information["approval"] == "true"
+
 	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
 	if err != nil {
 		log.Println(err)
@@ -117,8 +401,12 @@ func CreateTournament(c *gin.Context) { // test
 		return
 	}
 
-	_, err = conn.Exec(context.Background(), "insert into tournaments (name, owner_id, status, start, created_at, updated_at) "+
-		"values ($1, $2, $3, $4, current_timestamp, null)", name, id, StatusPending, startTS)
+	_, err = conn.Exec(context.Background(), "insert into tournaments (name, owner_id, status, start, format, pairing_system, "+
+		"self_reporting, bye_value, tiebreaks, swiss_rounds, cut, k_factor, rating_system, acceleration, points_win, points_draw, points_loss, draws, "+
+		"armageddon_win, armageddon_loss, best_of, team_boards, team_ranking, max_players, approval, created_at, updated_at) values ($1, $2, $3, "+
+		"$4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, current_timestamp, null)", name, id, StatusDraft, startTS, format, pairingSystem, selfReporting,
+		byeValue, tiebreaks, swissRounds, cut, kFactor, ratingSystem, acceleration, scoring.Win, scoring.Draw, scoring.Loss, scoring.Draws,
+		scoring.ArmageddonWin, scoring.ArmageddonLoss, bestOf, teamBoards, teamRanking, maxPlayers, approval)
 	if err != nil {
 		log.Println(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error couldn't put the information about the tournament in the database"})
@@ -154,23 +442,21 @@ func UpdateTournament(c *gin.Context) { // test
 	}
 	tournamentID := int(tournamentIDFl)
 
-	useName := false
-	name, ok := information["name"].(string)
-	if !ok {
-		useName = true
-	}
-
-	start, ok := information["start"].(string)
-	if !ok && !useName {
+	name, hasName := information["name"].(string)
+	start, hasStart := information["start"].(string)
+	if !hasName && !hasStart {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Error no information provided"})
 		return
 	}
 
-	startTS, err := time.Parse(time.RFC3339, start)
-	if err != nil {
-		log.Println(err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to parse the date and time"})
-		return
+	var startTS time.Time
+	if hasStart {
+		startTS, err = time.Parse(time.RFC3339, start)
+		if err != nil {
+			log.Println(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to parse the date and time"})
+			return
+		}
 	}
 
 	conn, err := pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
@@ -181,42 +467,43 @@ func UpdateTournament(c *gin.Context) { // test
 	}
 	defer conn.Close(context.Background())
 
-	ownerID, err := GetTournamentOwnerID(conn, tournamentID)
-	if err != nil {
+	tournament := Tournament{ID: tournamentID}
+	if err = tournament.GetTournament(conn); err != nil {
 		log.Println(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error couldn't check the owner of the tournament"})
 		return
 	}
 
-	if accountType != Admin && id != ownerID {
+	if accountType != Admin && id != tournament.OwnerID {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Error only admins and owners can edit the tournaments"})
 		return
 	}
 
-	if useName && ok {
-		_, err = conn.Exec(context.Background(), "update tournaments set name = $1, start = $2, updated_at = current_timestamp",
-			name, startTS)
-		if err != nil {
-			log.Println(err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to update the tournament"})
-			return
-		}
-	} else if useName {
-		_, err = conn.Exec(context.Background(), "update tournaments set name = $1, updated_at = current_timestamp",
-			name)
-		if err != nil {
-			log.Println(err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to update the tournament"})
-			return
-		}
-	} else if ok {
-		_, err = conn.Exec(context.Background(), "update tournaments set start = $1, updated_at = current_timestamp",
-			name)
-		if err != nil {
-			log.Println(err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to update the tournament"})
-			return
-		}
+	if tournament.IsOver() {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrTournamentOver.Error()})
+		return
+	}
+
+	if hasStart && !tournament.BeforeStart() {
+		c.JSON(http.StatusConflict, gin.H{"error": ErrAlreadyStarted.Error()})
+		return
+	}
+
+	switch {
+	case hasName && hasStart:
+		_, err = conn.Exec(context.Background(), "update tournaments set name = $1, start = $2, updated_at = current_timestamp where id = $3",
+			name, startTS, tournamentID)
+	case hasName:
+		_, err = conn.Exec(context.Background(), "update tournaments set name = $1, updated_at = current_timestamp where id = $2",
+			name, tournamentID)
+	default:
+		_, err = conn.Exec(context.Background(), "update tournaments set start = $1, updated_at = current_timestamp where id = $2",
+			startTS, tournamentID)
+	}
+	if err != nil {
+		log.Println(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to update the tournament"})
+		return
 	}
 
 	rows, err := conn.Query(context.Background(), "select user_id from players p where p.tournament_id = $1", tournamentID)
@@ -245,6 +532,12 @@ func UpdateTournament(c *gin.Context) { // test
 		return
 	}
 
+	// Nobody plays in the tournament yet, so there is nobody to notify
+	if len(userIDs) == 0 {
+		c.JSON(http.StatusOK, nil)
+		return
+	}
+
 	query := "select email from authentication a where a.id in ("
 	for i := range userIDs {
 		query += "$" + fmt.Sprintf("%d", i+1)
@@ -341,7 +634,7 @@ func GetTournament(c *gin.Context) { // test
 		return
 	}
 
-	tournament := Tournament{}
+	tournament := Tournament{ID: tournamentID}
 
 	if accountType != Admin && id != ownerID {
 		tournament.GetTournament(conn)
@@ -386,15 +679,26 @@ func DeleteTournament(c *gin.Context) {
 	}
 	defer conn.Close(context.Background())
 
-	ownerID, err := GetTournamentOwnerID(conn, tournamentID)
-	if err != nil {
+	tournament := Tournament{ID: tournamentID}
+	if err = tournament.GetTournament(conn); err != nil {
+		if err == pgx.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Error a tournament with this id doesn't exists"})
+			return
+		}
+
 		log.Println(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the owner of the tournament from the database"})
 		return
 	}
 
-	if accountType != Admin && id != ownerID {
+	if accountType != Admin && id != tournament.OwnerID {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Error only admins and owners can delete tournaments"})
+		return
+	}
+
+	if tournament.Status == StatusInProgress {
+		c.JSON(http.StatusConflict, gin.H{"error": "Error a tournament in progress has to be cancelled before it's deleted"})
+		return
 	}
 
 	check := 0
@@ -462,15 +766,8 @@ func GetTournamentsWithStatus(c *gin.Context) {
 		return
 	}
 
-	realStatus := 0
-	switch status {
-	case "pending":
-		realStatus = StatusPending
-	case "active":
-		realStatus = StatusActive
-	case "finished":
-		realStatus = StatusFinished
-	default:
+	realStatus, ok := ParseStatus(status)
+	if !ok {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Error invalid status type"})
 		return
 	}
@@ -483,7 +780,7 @@ func GetTournamentsWithStatus(c *gin.Context) {
 	}
 	defer conn.Close(context.Background())
 
-	rows, err := conn.Query(context.Background(), "select id, name, owner_id, start from tournaments t where t.status = $1", realStatus)
+	rows, err := conn.Query(context.Background(), "select id, name, owner_id, status, start from tournaments t where t.status = $1", realStatus)
 	if err != nil {
 		log.Println(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unable to get the information about the tournaments from the database"})